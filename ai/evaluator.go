@@ -0,0 +1,113 @@
+package ai
+
+import "github.com/deitrix/tetris/cell"
+
+// Evaluator scores a resulting board position after a candidate piece placement. Higher is
+// better; Best uses it to rank every legal placement of the falling piece.
+type Evaluator interface {
+	Score(board [][]cell.Tint, linesCleared int) float64
+}
+
+// Weights are the tunable coefficients of DefaultEvaluator's features.
+type Weights struct {
+	AggregateHeight float64
+	Bumpiness       float64
+	Holes           float64
+	WellDepth       float64
+	LinesCleared    float64
+}
+
+// DefaultWeights is a Dellacherie/Bertsekas-style starting point: height, bumpiness and holes are
+// penalized, clearing lines is rewarded, and a single deep well (for an I piece tetris) is
+// tolerated.
+var DefaultWeights = Weights{
+	AggregateHeight: -0.510066,
+	Bumpiness:       -0.184483,
+	Holes:           -0.35663,
+	WellDepth:       -0.1,
+	LinesCleared:    0.760666,
+}
+
+// DefaultEvaluator scores a board using weighted aggregate column height, bumpiness, hole count,
+// well depth, and lines cleared by the move that produced it.
+type DefaultEvaluator struct {
+	Weights Weights
+}
+
+func (e DefaultEvaluator) Score(board [][]cell.Tint, linesCleared int) float64 {
+	heights := columnHeights(board)
+
+	var aggHeight, bumpiness int
+	for x, h := range heights {
+		aggHeight += h
+		if x > 0 {
+			bumpiness += abs(heights[x-1] - h)
+		}
+	}
+
+	return e.Weights.AggregateHeight*float64(aggHeight) +
+		e.Weights.Bumpiness*float64(bumpiness) +
+		e.Weights.Holes*float64(countHoles(board)) +
+		e.Weights.WellDepth*float64(wellDepth(heights)) +
+		e.Weights.LinesCleared*float64(linesCleared)
+}
+
+// columnHeights returns, for each column, the number of rows from the floor up to (and including)
+// its topmost filled cell.
+func columnHeights(board [][]cell.Tint) []int {
+	width := len(board[0])
+	heights := make([]int, width)
+	for x := 0; x < width; x++ {
+		for y := 0; y < len(board); y++ {
+			if board[y][x] != 0 {
+				heights[x] = len(board) - y
+				break
+			}
+		}
+	}
+	return heights
+}
+
+// countHoles counts empty cells that have a filled cell somewhere above them in the same column.
+func countHoles(board [][]cell.Tint) int {
+	width := len(board[0])
+	holes := 0
+	for x := 0; x < width; x++ {
+		filledAbove := false
+		for y := 0; y < len(board); y++ {
+			switch {
+			case board[y][x] != 0:
+				filledAbove = true
+			case filledAbove:
+				holes++
+			}
+		}
+	}
+	return holes
+}
+
+// wellDepth sums how far each column sits below both of its neighbours, treating the board edges
+// as infinitely tall neighbours so edge columns aren't counted as wells.
+func wellDepth(heights []int) int {
+	depth := 0
+	for x, h := range heights {
+		left, right := 1<<30, 1<<30
+		if x > 0 {
+			left = heights[x-1]
+		}
+		if x < len(heights)-1 {
+			right = heights[x+1]
+		}
+		if neighbour := min(left, right); neighbour > h {
+			depth += neighbour - h
+		}
+	}
+	return depth
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,44 @@
+package ai
+
+import "github.com/deitrix/tetris/engine"
+
+// Player drives an Engine autonomously, re-planning with Best whenever it runs out of queued
+// inputs and feeding them back at a human-like cadence rather than all at once.
+type Player struct {
+	Evaluator Evaluator
+	// Lookahead is how many queued pieces beyond the falling one to search, in addition to it.
+	Lookahead int
+	// MoveInterval is the number of ticks to wait between each input of the current plan.
+	MoveInterval int
+
+	plan        []engine.Input
+	ticksToMove int
+}
+
+// NewPlayer returns a Player using eval to score candidate placements, with a lookahead of one
+// queued piece and a default move interval of 4 ticks.
+func NewPlayer(eval Evaluator) *Player {
+	return &Player{Evaluator: eval, Lookahead: 1, MoveInterval: 4}
+}
+
+// Step returns the next input the player wants to apply. It should be called once per engine
+// tick; the caller is responsible for feeding the result into Engine.Step.
+func (p *Player) Step(e *engine.Engine) engine.Input {
+	if len(p.plan) == 0 {
+		move, ok := Best(e, p.Evaluator, p.Lookahead)
+		if !ok {
+			return engine.Input{}
+		}
+		p.plan = move.Inputs
+	}
+
+	if p.ticksToMove > 0 {
+		p.ticksToMove--
+		return engine.Input{}
+	}
+
+	input := p.plan[0]
+	p.plan = p.plan[1:]
+	p.ticksToMove = p.MoveInterval
+	return input
+}
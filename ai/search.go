@@ -0,0 +1,92 @@
+package ai
+
+import "github.com/deitrix/tetris/engine"
+
+// Placement identifies a candidate landing spot for the falling piece: a number of clockwise
+// rotations from its current orientation, then a target column for its X.
+type Placement struct {
+	Rotations int
+	X         int
+}
+
+// Move is a placement together with the score it earned and the sequence of inputs needed to
+// reach it from the engine's current state.
+type Move struct {
+	Placement Placement
+	Score     float64
+	Inputs    []engine.Input
+}
+
+// Best searches every legal (rotation, column) placement of e's falling piece, and - if lookahead
+// is greater than zero - recurses into the resulting position to also account for placing the
+// next queued piece, picking the placement with the highest total score.
+//
+// Best mutates e while searching, but always restores it to its original state before returning -
+// including e.Randomizer, via Engine.Snapshot/Restore. That matters because each trial placement
+// locks a real piece, consuming real draws from e.Randomizer; without restoring the randomizer
+// itself, those speculative draws would leak into the real game and desync any recorded replay.
+func Best(e *engine.Engine, eval Evaluator, lookahead int) (Move, bool) {
+	snapshot := e.Snapshot()
+	defer e.Restore(snapshot)
+
+	var best Move
+	found := false
+	for rotations := 0; rotations < 4; rotations++ {
+		for x := -2; x < engine.Width+2; x++ {
+			e.Restore(snapshot)
+			inputs, lines, ok := tryPlacement(e, rotations, x)
+			if !ok {
+				continue
+			}
+
+			score := eval.Score(e.Board, lines)
+			if lookahead > 0 && !e.GameOver {
+				if next, ok := Best(e, eval, lookahead-1); ok {
+					score += next.Score
+				}
+			}
+
+			if !found || score > best.Score {
+				best = Move{Placement: Placement{Rotations: rotations, X: x}, Score: score, Inputs: inputs}
+				found = true
+			}
+		}
+	}
+	return best, found
+}
+
+// tryPlacement rotates and shifts e's falling piece to reach the given placement, then hard-drops
+// it, reporting the inputs that reproduce the move and the number of lines it cleared. It reports
+// ok=false if the placement could not be reached (e.g. the rotation doesn't fit, or no kick moves
+// the piece far enough to reach column x).
+func tryPlacement(e *engine.Engine, rotations, x int) (inputs []engine.Input, lines int, ok bool) {
+	for i := 0; i < rotations; i++ {
+		if !e.Rotate(engine.RotateCW) {
+			return nil, 0, false
+		}
+		inputs = append(inputs, engine.Input{RotateCW: true})
+	}
+	for e.Falling.X < x {
+		if !e.MoveRight() {
+			return nil, 0, false
+		}
+		inputs = append(inputs, engine.Input{Right: true})
+	}
+	for e.Falling.X > x {
+		if !e.MoveLeft() {
+			return nil, 0, false
+		}
+		inputs = append(inputs, engine.Input{Left: true})
+	}
+	if e.Falling.X != x {
+		return nil, 0, false
+	}
+
+	for _, ev := range e.HardDrop() {
+		if ev.Type == engine.EventLinesCleared {
+			lines = ev.Lines
+		}
+	}
+	inputs = append(inputs, engine.Input{HardDrop: true})
+	return inputs, lines, true
+}
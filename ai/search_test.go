@@ -0,0 +1,50 @@
+package ai
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/deitrix/tetris/engine"
+	"github.com/deitrix/tetris/piece"
+	"github.com/deitrix/tetris/replay"
+)
+
+// TestBest_PreservesReplayDeterminism is a regression test for a bug where Best's speculative
+// placements consumed real draws from the engine's Randomizer without restoring them afterwards,
+// so a replay recorded alongside an AI-driven game silently diverged from what playing it back
+// reproduced.
+func TestBest_PreservesReplayDeterminism(t *testing.T) {
+	const seed = 12345
+	const ticks = 400
+
+	e := engine.New(piece.NewBagRandomizer(seed))
+	player := NewPlayer(DefaultEvaluator{Weights: DefaultWeights})
+
+	var buf bytes.Buffer
+	rec, err := replay.NewRecorder(&buf, seed, replay.Ruleset{SevenBag: true})
+	if err != nil {
+		t.Fatalf("NewRecorder: %v", err)
+	}
+	for tick := 0; tick < ticks; tick++ {
+		input := player.Step(e)
+		if err := rec.Record(tick, input); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+		e.Step(input)
+	}
+	if err := rec.Close(replay.Footer{FinalScore: e.Score, FinalLines: e.LinesCleared, FinalTick: ticks - 1}); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	rep, err := replay.Load(&buf)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	replayed := rep.NewEngine()
+	rep.Play(replayed)
+
+	if replayed.Score != e.Score || replayed.LinesCleared != e.LinesCleared {
+		t.Fatalf("replay diverged from the live game: live score=%d lines=%d, replay score=%d lines=%d",
+			e.Score, e.LinesCleared, replayed.Score, replayed.LinesCleared)
+	}
+}
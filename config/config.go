@@ -0,0 +1,140 @@
+// Package config loads a player's rules and control profile from a JSON file, so the engine's
+// timings and the game's key bindings don't have to be hardcoded. Default matches the game's
+// original behaviour, so a player with no config file sees no change.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/deitrix/tetris/engine"
+	"github.com/deitrix/tetris/piece"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Path returns the default config file location: ~/.config/tetris/config.json.
+func Path() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("finding home directory: %w", err)
+	}
+	return filepath.Join(home, ".config", "tetris", "config.json"), nil
+}
+
+// Config controls the rules and control profile of a game.
+type Config struct {
+	// DASFrames is how many frames Left/Right must be held before auto-repeat kicks in.
+	DASFrames int
+	// ARRFrames is how many frames auto-repeat waits between each repeated move once DASFrames has
+	// elapsed. 0 repeats every frame.
+	ARRFrames int
+	// SoftDropTicks is the number of ticks between automatic falls while soft-dropping.
+	SoftDropTicks int
+	// LockDelayTicks is how many ticks without movement/rotation a grounded piece tolerates before
+	// it locks.
+	LockDelayTicks int
+	// LastChanceTicks is the maximum number of ticks a grounded piece can be kept alive past
+	// LockDelayTicks by moving or rotating it, before it locks regardless.
+	LastChanceTicks int
+	// LinesPerLevel is how many cleared lines it takes to advance one level.
+	LinesPerLevel int
+	// MaxLevel is the highest level the engine will advance to.
+	MaxLevel int
+	// StartLevel is the level a new game begins at.
+	StartLevel int
+	// OpacityStep is how much the falling piece's render opacity changes per tick while it flashes
+	// during its last-chance period.
+	OpacityStep int
+	// ShowGhost shows a preview of where the falling piece will land.
+	ShowGhost bool
+	// HoldEnabled allows the player to hold a piece for later.
+	HoldEnabled bool
+	// Randomizer selects the piece.Randomizer a new game uses: "bag", "uniform", or "tgm".
+	Randomizer string
+	// Keys binds each control to a keyboard key.
+	Keys Keys
+}
+
+// Default matches the game's original hardcoded behaviour.
+var Default = Config{
+	DASFrames:       10,
+	ARRFrames:       0,
+	SoftDropTicks:   1,
+	LockDelayTicks:  30,
+	LastChanceTicks: 120,
+	LinesPerLevel:   10,
+	MaxLevel:        29,
+	StartLevel:      0,
+	OpacityStep:     8,
+	ShowGhost:       true,
+	HoldEnabled:     true,
+	Randomizer:      "bag",
+	Keys:            DefaultKeys,
+}
+
+// Rules extracts the subset of Config that governs engine.Engine's own timings.
+func (c Config) Rules() engine.Rules {
+	return engine.Rules{
+		LockDelayTicks:  c.LockDelayTicks,
+		LastChanceTicks: c.LastChanceTicks,
+		SoftDropTicks:   c.SoftDropTicks,
+		LinesPerLevel:   c.LinesPerLevel,
+		MaxLevel:        c.MaxLevel,
+	}
+}
+
+// NewRandomizer builds the piece.Randomizer named by c.Randomizer, seeded with seed. An
+// unrecognised name falls back to the 7-bag randomizer.
+func (c Config) NewRandomizer(seed int64) piece.Randomizer {
+	switch c.Randomizer {
+	case "uniform":
+		return piece.NewUniformRandomizer(seed)
+	case "tgm":
+		return piece.NewTGMRandomizer(seed)
+	default:
+		return piece.NewBagRandomizer(seed)
+	}
+}
+
+// Keys binds each control to a keyboard key.
+type Keys struct {
+	Left      ebiten.Key
+	Right     ebiten.Key
+	SoftDrop  ebiten.Key
+	HardDrop  ebiten.Key
+	RotateCW  ebiten.Key
+	RotateCCW ebiten.Key
+	Hold      ebiten.Key
+	Pause     ebiten.Key
+}
+
+// DefaultKeys matches the key bindings the game shipped with before they became configurable.
+var DefaultKeys = Keys{
+	Left:      ebiten.KeyLeft,
+	Right:     ebiten.KeyRight,
+	SoftDrop:  ebiten.KeyDown,
+	HardDrop:  ebiten.KeySpace,
+	RotateCW:  ebiten.KeyUp,
+	RotateCCW: ebiten.KeyZ,
+	Hold:      ebiten.KeyC,
+	Pause:     ebiten.KeyEscape,
+}
+
+// Load reads and parses the config file at path, returning Default if it doesn't exist. Fields
+// omitted from the file keep their Default value.
+func Load(path string) (Config, error) {
+	cfg := Default
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return Config{}, fmt.Errorf("reading config: %w", err)
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("parsing config: %w", err)
+	}
+	return cfg, nil
+}
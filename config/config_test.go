@@ -0,0 +1,57 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+func TestLoad_MissingFileReturnsDefault(t *testing.T) {
+	cfg, err := Load(filepath.Join(t.TempDir(), "does-not-exist.json"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg != Default {
+		t.Fatalf("Load(missing file) = %+v, want Default %+v", cfg, Default)
+	}
+}
+
+func TestLoad_PartialOverrideKeepsOtherDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeFile(t, path, `{"DASFrames": 5, "Keys": {"Left": "A"}}`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.DASFrames != 5 {
+		t.Errorf("DASFrames = %d, want 5", cfg.DASFrames)
+	}
+	if cfg.ARRFrames != Default.ARRFrames {
+		t.Errorf("ARRFrames = %d, want unchanged default %d", cfg.ARRFrames, Default.ARRFrames)
+	}
+	if cfg.Keys.Left != ebiten.KeyA {
+		t.Errorf("Keys.Left = %v, want %v", cfg.Keys.Left, ebiten.KeyA)
+	}
+	if cfg.Keys.Right != DefaultKeys.Right {
+		t.Errorf("Keys.Right = %v, want unchanged default %v", cfg.Keys.Right, DefaultKeys.Right)
+	}
+}
+
+func TestLoad_UnknownKeyNameErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeFile(t, path, `{"Keys": {"Left": "Bogus"}}`)
+
+	if _, err := Load(path); err == nil {
+		t.Fatal("Load with an unknown key name: got nil error, want one")
+	}
+}
+
+func writeFile(t *testing.T, path, contents string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatalf("writing %s: %v", path, err)
+	}
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// keyNames maps the human-readable key names accepted in a config file to their ebiten.Key.
+var keyNames = map[string]ebiten.Key{
+	"Left":    ebiten.KeyLeft,
+	"Right":   ebiten.KeyRight,
+	"Up":      ebiten.KeyUp,
+	"Down":    ebiten.KeyDown,
+	"Space":   ebiten.KeySpace,
+	"Escape":  ebiten.KeyEscape,
+	"Shift":   ebiten.KeyShift,
+	"Control": ebiten.KeyControl,
+	"A":       ebiten.KeyA,
+	"C":       ebiten.KeyC,
+	"X":       ebiten.KeyX,
+	"Z":       ebiten.KeyZ,
+}
+
+func keyName(k ebiten.Key) string {
+	for name, candidate := range keyNames {
+		if candidate == k {
+			return name
+		}
+	}
+	return ""
+}
+
+// keysJSON mirrors Keys with string fields, for (un)marshalling key names instead of raw key
+// codes.
+type keysJSON struct {
+	Left, Right, SoftDrop, HardDrop, RotateCW, RotateCCW, Hold, Pause string
+}
+
+func (k Keys) MarshalJSON() ([]byte, error) {
+	return json.Marshal(keysJSON{
+		Left:      keyName(k.Left),
+		Right:     keyName(k.Right),
+		SoftDrop:  keyName(k.SoftDrop),
+		HardDrop:  keyName(k.HardDrop),
+		RotateCW:  keyName(k.RotateCW),
+		RotateCCW: keyName(k.RotateCCW),
+		Hold:      keyName(k.Hold),
+		Pause:     keyName(k.Pause),
+	})
+}
+
+// UnmarshalJSON sets only the keys named in data, leaving any others at whatever value k already
+// holds - so loading a config file that only overrides a couple of bindings keeps the rest at
+// their defaults.
+func (k *Keys) UnmarshalJSON(data []byte) error {
+	var raw keysJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	for _, b := range []struct {
+		name string
+		dst  *ebiten.Key
+	}{
+		{raw.Left, &k.Left},
+		{raw.Right, &k.Right},
+		{raw.SoftDrop, &k.SoftDrop},
+		{raw.HardDrop, &k.HardDrop},
+		{raw.RotateCW, &k.RotateCW},
+		{raw.RotateCCW, &k.RotateCCW},
+		{raw.Hold, &k.Hold},
+		{raw.Pause, &k.Pause},
+	} {
+		if b.name == "" {
+			continue
+		}
+		key, ok := keyNames[b.name]
+		if !ok {
+			return fmt.Errorf("unknown key name %q", b.name)
+		}
+		*b.dst = key
+	}
+	return nil
+}
@@ -0,0 +1,644 @@
+// Package engine implements the rules of Tetris - the board, falling piece, scoring, and timing -
+// with no dependency on ebiten or any other rendering/input library. This makes it possible to
+// drive a game from something other than a window: tests, AI, replays, or a network peer.
+package engine
+
+import (
+	"slices"
+
+	"github.com/deitrix/tetris/cell"
+	"github.com/deitrix/tetris/piece"
+)
+
+const (
+	// Width is the width of the playable board, in cells.
+	Width = 10
+	// Height is the height of the playable board, in cells.
+	Height = 20
+	// QueueSize is the number of pieces visible in the upcoming queue.
+	QueueSize = 3
+)
+
+// fallSpeed is the number of ticks between each automatic fall of the piece at each level.
+var fallSpeed = map[int]int{
+	0:  53,
+	1:  49,
+	2:  45,
+	3:  41,
+	4:  37,
+	5:  33,
+	6:  28,
+	7:  22,
+	8:  17,
+	9:  11,
+	10: 10,
+	11: 9,
+	12: 8,
+	13: 7,
+	14: 6,
+	16: 5,
+	18: 4,
+	20: 3,
+	22: 2,
+	29: 1,
+}
+
+func getFallSpeed(level int) int {
+	if level > 29 {
+		return 1
+	}
+	if speed, ok := fallSpeed[level]; ok {
+		return speed
+	}
+	return getFallSpeed(level - 1)
+}
+
+var lineScore = map[int]int{
+	1: 40,
+	2: 100,
+	3: 300,
+	4: 1200,
+}
+
+func getLineScore(level, n int) int {
+	return lineScore[n] * (level + 1)
+}
+
+// tSpinScore is the bonus awarded for a full T-spin, keyed by the number of lines cleared by the
+// same placement.
+var tSpinScore = map[int]int{
+	0: 400,
+	1: 800,
+	2: 1200,
+	3: 1600,
+}
+
+// miniTSpinScore is the bonus awarded for a mini T-spin, keyed by the number of lines cleared by
+// the same placement.
+var miniTSpinScore = map[int]int{
+	0: 100,
+	1: 200,
+}
+
+func getTSpinScore(level int, tspin, mini bool, lines int) int {
+	if !tspin {
+		return 0
+	}
+	if mini {
+		return miniTSpinScore[lines] * (level + 1)
+	}
+	return tSpinScore[lines] * (level + 1)
+}
+
+// Action records the kind of action most recently applied to the falling piece, used to determine
+// T-spin eligibility when the piece locks.
+type Action int
+
+const (
+	ActionNone Action = iota
+	ActionMove
+	ActionRotate
+)
+
+// RotationDir identifies which way the falling piece should rotate.
+type RotationDir int
+
+const (
+	RotateCW RotationDir = iota
+	RotateCCW
+	Rotate180
+)
+
+// Rules are the tunable thresholds governing how an Engine behaves, as distinct from its board
+// state. New uses DefaultRules; NewWithRules lets a caller (e.g. the config package) override
+// them.
+type Rules struct {
+	// LockDelayTicks is how many ticks without movement/rotation a grounded piece tolerates before
+	// it locks.
+	LockDelayTicks int
+	// LastChanceTicks is the maximum number of ticks a grounded piece can be kept alive past
+	// LockDelayTicks by moving or rotating it, before it locks regardless.
+	LastChanceTicks int
+	// SoftDropTicks is the number of ticks between automatic falls while soft-dropping.
+	SoftDropTicks int
+	// LinesPerLevel is how many cleared lines it takes to advance one level.
+	LinesPerLevel int
+	// MaxLevel is the highest level the engine will advance to.
+	MaxLevel int
+}
+
+// DefaultRules matches the game's original hardcoded behaviour.
+var DefaultRules = Rules{
+	LockDelayTicks:  30,
+	LastChanceTicks: 120,
+	SoftDropTicks:   1,
+	LinesPerLevel:   10,
+	MaxLevel:        29,
+}
+
+// Engine holds the full state of a single game of Tetris and the rules for advancing it. It has no
+// concept of frames-per-second, windows, or keyboard state - callers drive it with Step or the
+// individual action methods, and tick it forward with Tick.
+type Engine struct {
+	// Rules are the thresholds this Engine advances by.
+	Rules Rules
+	// Board holds the committed cells, indexed Board[y][x]. A zero-value cell.Tint means empty.
+	Board [][]cell.Tint
+	// Queue is the upcoming pieces, not including the falling piece.
+	Queue []piece.Piece
+	// Falling is the piece currently being controlled.
+	Falling piece.Piece
+	// HoldPiece is the piece being held for later, or nil if none has been held yet.
+	HoldPiece *piece.Piece
+	// DidHoldPiece prevents holding more than once per falling piece.
+	DidHoldPiece bool
+	// FastFalling indicates the piece is currently being soft-dropped.
+	FastFalling bool
+	// TicksSinceFall is the number of ticks since the piece last fell, used to time automatic
+	// gravity.
+	TicksSinceFall int
+	// TicksSinceMove is the number of ticks since the piece last moved, used during the
+	// "last chance" period to determine when the piece should be committed.
+	TicksSinceMove int
+	// LastChanceTicks is the number of ticks since the piece landed, used to determine when the
+	// piece should be committed during the "last chance" period.
+	LastChanceTicks int
+	// LastAction is the most recent action applied to the falling piece, used to determine T-spin
+	// eligibility when it locks.
+	LastAction Action
+	// LastKickIndex is the index into the SRS kick table used to land the most recent rotation, or
+	// -1 if the rotation needed no kick or no rotation has happened yet.
+	LastKickIndex int
+	// Level is the current level.
+	Level int
+	// Score is the current score.
+	Score int
+	// LinesCleared is the total number of lines cleared so far.
+	LinesCleared int
+	// GameOver is set once a newly-spawned piece has nowhere to go.
+	GameOver bool
+	// Randomizer determines the sequence of pieces dealt into the queue.
+	Randomizer piece.Randomizer
+}
+
+// New creates an Engine with an empty board and a freshly-filled queue, ready to play, using
+// DefaultRules.
+func New(r piece.Randomizer) *Engine {
+	return NewWithRules(r, DefaultRules)
+}
+
+// NewWithRules creates an Engine as New does, but governed by rules instead of DefaultRules.
+func NewWithRules(r piece.Randomizer, rules Rules) *Engine {
+	board := make([][]cell.Tint, Height)
+	for y := range board {
+		board[y] = make([]cell.Tint, Width)
+	}
+	e := &Engine{
+		Rules:         rules,
+		Board:         board,
+		Queue:         make([]piece.Piece, QueueSize),
+		LastKickIndex: -1,
+		Randomizer:    r,
+	}
+	e.fillQueue()
+	e.loadNextPiece()
+	return e
+}
+
+// Input is the set of actions requested for a single Step.
+type Input struct {
+	Left, Right, SoftDrop, HardDrop, Hold bool
+	RotateCW, RotateCCW, Rotate180        bool
+}
+
+// Step applies one tick's worth of player input and then advances gravity/lock-delay by a single
+// tick, returning every notable event that occurred along the way. This is the entry point an
+// adapter polling input once per frame should use; MoveLeft, Rotate, Hold, HardDrop and Tick are
+// exposed separately for callers (tests, AI, replay) that want to drive the engine directly.
+func (e *Engine) Step(input Input) []Event {
+	if e.GameOver {
+		return nil
+	}
+
+	if input.Hold && e.Hold() {
+		return []Event{{Type: EventHeld}}
+	}
+
+	if input.HardDrop {
+		return e.HardDrop()
+	}
+
+	var didMove bool
+	if input.Left && e.MoveLeft() {
+		didMove = true
+	}
+	if input.Right && e.MoveRight() {
+		didMove = true
+	}
+
+	var rotated bool
+	switch {
+	case input.RotateCW:
+		rotated = e.Rotate(RotateCW)
+	case input.RotateCCW:
+		rotated = e.Rotate(RotateCCW)
+	case input.Rotate180:
+		rotated = e.Rotate(Rotate180)
+	}
+
+	e.FastFalling = input.SoftDrop
+	if didMove || rotated {
+		e.TicksSinceMove = 0
+	} else {
+		e.TicksSinceMove++
+	}
+
+	events := e.Tick(1)
+	if rotated {
+		events = append([]Event{{Type: EventRotated}}, events...)
+	}
+	if didMove {
+		events = append([]Event{{Type: EventMoved}}, events...)
+	}
+	return events
+}
+
+// MoveLeft moves the falling piece one cell left, if it fits. It reports whether the move
+// succeeded.
+func (e *Engine) MoveLeft() bool {
+	p := e.Falling
+	p.X--
+	if !e.fits(p) {
+		return false
+	}
+	e.Falling = p
+	e.LastAction = ActionMove
+	return true
+}
+
+// MoveRight moves the falling piece one cell right, if it fits. It reports whether the move
+// succeeded.
+func (e *Engine) MoveRight() bool {
+	p := e.Falling
+	p.X++
+	if !e.fits(p) {
+		return false
+	}
+	e.Falling = p
+	e.LastAction = ActionMove
+	return true
+}
+
+// MoveDown moves the falling piece one cell down, if it fits. It reports whether the move
+// succeeded.
+func (e *Engine) MoveDown() bool {
+	if !e.canMoveDown(e.Falling) {
+		return false
+	}
+	e.Falling.Y++
+	e.LastAction = ActionMove
+	e.TicksSinceFall = 0
+	return true
+}
+
+// Rotate attempts to rotate the falling piece in the given direction using the SRS kick table for
+// its type. It tries each of the 5 test offsets for the (oldOrientation -> newOrientation)
+// transition in turn, applying the first one that fits. If none fit, the rotation is cancelled and
+// Rotate returns false.
+func (e *Engine) Rotate(dir RotationDir) bool {
+	from := e.Falling.Orientation
+	rotated := e.Falling.Clone()
+	switch dir {
+	case RotateCW:
+		rotated.RotateCW()
+	case RotateCCW:
+		rotated.RotateCCW()
+	case Rotate180:
+		rotated.Rotate180()
+	}
+	if from == rotated.Orientation {
+		return false
+	}
+	for i, off := range piece.Kicks(rotated, from, rotated.Orientation) {
+		test := rotated
+		test.X += off.X
+		test.Y += off.Y
+		if e.fits(test) {
+			e.Falling = test
+			e.LastAction = ActionRotate
+			e.LastKickIndex = i
+			return true
+		}
+	}
+	return false
+}
+
+// Hold swaps the falling piece into the hold slot, or does nothing and returns false if the
+// player has already held this turn.
+func (e *Engine) Hold() bool {
+	if e.DidHoldPiece {
+		return false
+	}
+	if e.HoldPiece == nil {
+		p := e.Falling.Clone()
+		e.HoldPiece = &p
+		e.loadNextPiece()
+	} else {
+		e.Falling, *e.HoldPiece = *e.HoldPiece, e.Falling
+	}
+	e.Falling.ResetRotation()
+	e.Falling.X = Width/2 - e.Falling.Width/2
+	e.Falling.Y = 0
+	e.HoldPiece.ResetRotation()
+	e.HoldPiece.X = 0
+	e.HoldPiece.Y = 0
+	e.DidHoldPiece = true
+	return true
+}
+
+// HardDrop drops the falling piece straight to the floor, awards early-commit points for the
+// distance travelled, and locks it.
+func (e *Engine) HardDrop() []Event {
+	dropped := e.DropDistance()
+	e.Falling.Y += dropped
+	e.Score += dropped * 2
+	return e.lock()
+}
+
+// DropDistance returns how many rows the falling piece could fall before it collides.
+func (e *Engine) DropDistance() int {
+	p := e.Falling
+	d := 0
+	for e.canMoveDown(p) {
+		p.Y++
+		d++
+	}
+	return d
+}
+
+// GhostPiece returns the falling piece translated down to where it would land if hard-dropped now.
+func (e *Engine) GhostPiece() piece.Piece {
+	p := e.Falling
+	p.Y += e.DropDistance()
+	return p
+}
+
+// Tick advances gravity and lock-delay timing by dt ticks, locking the falling piece if it can no
+// longer fall and has run out of last-chance time. It returns every notable event produced.
+func (e *Engine) Tick(dt int) []Event {
+	var events []Event
+	for i := 0; i < dt && !e.GameOver; i++ {
+		events = append(events, e.tick()...)
+	}
+	return events
+}
+
+func (e *Engine) tick() []Event {
+	minTicksSinceFall := getFallSpeed(e.Level)
+	if e.FastFalling {
+		minTicksSinceFall = e.Rules.SoftDropTicks
+	}
+	if e.canMoveDown(e.Falling) {
+		if e.TicksSinceFall >= minTicksSinceFall {
+			e.Falling.Y++
+			e.LastAction = ActionMove
+			if e.FastFalling {
+				e.Score++
+			}
+			e.TicksSinceFall = 0
+			e.TicksSinceMove = 0
+		} else {
+			e.TicksSinceFall++
+		}
+		return nil
+	}
+	if e.TicksSinceMove >= e.Rules.LockDelayTicks || e.LastChanceTicks >= e.Rules.LastChanceTicks {
+		e.TicksSinceFall = 0
+		return e.lock()
+	}
+	e.LastChanceTicks++
+	return nil
+}
+
+// lock commits the falling piece into the board, scores it (including any T-spin bonus), clears
+// filled lines, loads the next piece, and checks for game over.
+func (e *Engine) lock() []Event {
+	p := e.Falling
+	for i := range p.Mask {
+		if p.Mask[i] == 0 {
+			continue
+		}
+		x := p.X + i%p.Width
+		y := p.Y + i/p.Width
+		e.Board[y][x] = p.Tint
+	}
+
+	tspin, mini := e.tSpinStatus(p)
+	level := e.Level // clearLines may bump e.Level; the T-spin bonus is scored at the pre-clear level.
+	e.loadNextPiece()
+	lines := e.clearLines()
+
+	events := []Event{{Type: EventLocked}}
+	if tspinScore := getTSpinScore(level, tspin, mini, lines); tspin {
+		e.Score += tspinScore
+		events = append(events, Event{Type: EventTSpin, Lines: lines, Mini: mini, Score: tspinScore})
+	}
+	if lines > 0 {
+		events = append(events, Event{Type: EventLinesCleared, Lines: lines})
+	}
+
+	e.DidHoldPiece = false
+	e.LastChanceTicks = 0
+
+	if !e.fits(e.Falling) {
+		e.GameOver = true
+		events = append(events, Event{Type: EventGameOver})
+	}
+	return events
+}
+
+// tSpinStatus reports whether the just-placed piece p qualifies for a T-spin bonus. A T-spin
+// requires the piece to be a T, locked by rotation rather than translation, with at least 3 of its
+// 4 diagonal corners filled. It's a "mini" T-spin rather than a full one unless both corners on the
+// side the T points towards are filled, or the rotation was landed using the TST kick (index 4).
+func (e *Engine) tSpinStatus(p piece.Piece) (tspin, mini bool) {
+	if len(p.Mask) != 9 || p.Tint != piece.T.Tint || e.LastAction != ActionRotate {
+		return false, false
+	}
+	filled := func(x, y int) bool {
+		return e.cellAt(x, y) != cell.Tint(0)
+	}
+	tl, tr := filled(p.X, p.Y), filled(p.X+2, p.Y)
+	bl, br := filled(p.X, p.Y+2), filled(p.X+2, p.Y+2)
+	corners := boolCount(tl, tr, bl, br)
+	if corners < 3 {
+		return false, false
+	}
+	var pointedCorners int
+	switch p.Orientation {
+	case 0: // T points up
+		pointedCorners = boolCount(tl, tr)
+	case 1: // T points right
+		pointedCorners = boolCount(tr, br)
+	case 2: // T points down
+		pointedCorners = boolCount(bl, br)
+	case 3: // T points left
+		pointedCorners = boolCount(tl, bl)
+	}
+	if pointedCorners == 2 || e.LastKickIndex == 4 {
+		return true, false
+	}
+	return true, true
+}
+
+func boolCount(bs ...bool) int {
+	n := 0
+	for _, b := range bs {
+		if b {
+			n++
+		}
+	}
+	return n
+}
+
+// fits reports whether p can occupy its current X/Y without colliding with the board edges or any
+// committed cell.
+func (e *Engine) fits(p piece.Piece) bool {
+	for i := range p.Mask {
+		if p.Mask[i] == 0 {
+			continue
+		}
+		x := p.X + i%p.Width
+		y := p.Y + i/p.Width
+		if x < 0 || x >= Width || y < 0 || y >= Height {
+			return false
+		}
+		if e.Board[y][x] != cell.Tint(0) {
+			return false
+		}
+	}
+	return true
+}
+
+// cellAt returns the board cell at (x, y), or a non-empty sentinel if (x, y) is outside the board
+// - corners of a piece sitting flush against the floor or a wall count as "filled" for T-spin
+// purposes, matching how SRS treats them.
+func (e *Engine) cellAt(x, y int) cell.Tint {
+	if x < 0 || x >= Width || y < 0 || y >= Height {
+		return cell.Wall
+	}
+	return e.Board[y][x]
+}
+
+func (e *Engine) canMoveDown(p piece.Piece) bool {
+	p.Y++
+	return e.fits(p)
+}
+
+// CanFall reports whether the falling piece could move down one row right now. Adapters can use
+// this to drive last-chance visual feedback without reaching into lock-delay internals.
+func (e *Engine) CanFall() bool {
+	return e.canMoveDown(e.Falling)
+}
+
+// AddGarbage inserts n rows at the bottom of the board, each filled solid except for a single
+// hole column, shifting every existing row up by n. Rows pushed off the top are lost, which can
+// only happen if the stack was already built up near the top of the board. The hole column is
+// chosen by the caller so that several rows from the same attack can share one hole, per modern
+// versus rules. It reports whether the falling piece still fits afterwards; if not, the game is
+// over.
+func (e *Engine) AddGarbage(n, hole int) bool {
+	for i := 0; i < n; i++ {
+		e.Board = append(e.Board[1:], make([]cell.Tint, Width))
+		row := e.Board[Height-1]
+		for x := range row {
+			if x != hole {
+				row[x] = cell.Wall
+			}
+		}
+	}
+	if !e.fits(e.Falling) {
+		e.GameOver = true
+		return false
+	}
+	return true
+}
+
+func (e *Engine) clearLines() int {
+	lines := 0
+	for y := 0; y < Height; y++ {
+		if e.rowFull(y) {
+			e.removeRow(y)
+			lines++
+		}
+	}
+	if lines > 0 {
+		e.Score += getLineScore(e.Level, lines)
+		e.LinesCleared += lines
+		e.Level = min(e.LinesCleared/e.Rules.LinesPerLevel, e.Rules.MaxLevel)
+	}
+	return lines
+}
+
+func (e *Engine) rowFull(y int) bool {
+	for x := 0; x < Width; x++ {
+		if e.Board[y][x] == cell.Tint(0) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *Engine) removeRow(row int) {
+	for y := row; y > 0; y-- {
+		copy(e.Board[y], e.Board[y-1])
+	}
+	for x := range e.Board[0] {
+		e.Board[0][x] = cell.Tint(0)
+	}
+}
+
+func (e *Engine) loadNextPiece() {
+	e.Falling = e.Queue[0]
+	copy(e.Queue, e.Queue[1:])
+	e.Queue[len(e.Queue)-1] = e.Randomizer.Next()
+	e.Falling.X = Width/2 - e.Falling.Width/2
+	e.Falling.Y = 0
+	e.LastAction = ActionNone
+}
+
+func (e *Engine) fillQueue() {
+	for i := range e.Queue {
+		e.Queue[i] = e.Randomizer.Next()
+	}
+}
+
+// Snapshot is an opaque, independent copy of an Engine's state, for later Restore.
+type Snapshot struct {
+	engine Engine
+}
+
+// Snapshot captures the current state of the engine for later restoration.
+func (e *Engine) Snapshot() Snapshot {
+	return Snapshot{engine: e.clone()}
+}
+
+// Restore replaces the engine's state with a previously-captured Snapshot.
+func (e *Engine) Restore(s Snapshot) {
+	*e = s.engine.clone()
+}
+
+func (e *Engine) clone() Engine {
+	cp := *e
+	cp.Board = make([][]cell.Tint, len(e.Board))
+	for y, row := range e.Board {
+		cp.Board[y] = slices.Clone(row)
+	}
+	cp.Queue = slices.Clone(e.Queue)
+	if e.HoldPiece != nil {
+		h := *e.HoldPiece
+		cp.HoldPiece = &h
+	}
+	cp.Randomizer = e.Randomizer.Clone()
+	return cp
+}
@@ -0,0 +1,143 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/deitrix/tetris/cell"
+	"github.com/deitrix/tetris/piece"
+)
+
+// sequenceRandomizer yields a fixed, repeating sequence of pieces, for deterministic tests.
+type sequenceRandomizer struct {
+	pieces []piece.Piece
+	i      int
+}
+
+func (r *sequenceRandomizer) Next() piece.Piece {
+	p := r.pieces[r.i%len(r.pieces)].Clone()
+	r.i++
+	return p
+}
+
+func (r *sequenceRandomizer) Clone() piece.Randomizer {
+	cp := *r
+	return &cp
+}
+
+func newTestEngine(pieces ...piece.Piece) *Engine {
+	return New(&sequenceRandomizer{pieces: pieces})
+}
+
+func TestEngine_ClearLines(t *testing.T) {
+	e := newTestEngine(piece.O)
+	for x := 0; x < Width; x++ {
+		e.Board[Height-1][x] = piece.O.Tint
+	}
+
+	lines := e.clearLines()
+	if lines != 1 {
+		t.Fatalf("clearLines() = %d, want 1", lines)
+	}
+	if e.LinesCleared != 1 {
+		t.Fatalf("LinesCleared = %d, want 1", e.LinesCleared)
+	}
+	if want := getLineScore(0, 1); e.Score != want {
+		t.Fatalf("Score = %d, want %d", e.Score, want)
+	}
+	if e.Board[Height-1][0] != 0 {
+		t.Fatalf("cleared row was not emptied")
+	}
+}
+
+func TestEngine_LevelProgression(t *testing.T) {
+	e := newTestEngine(piece.O)
+	e.LinesCleared = 9
+	for x := 0; x < Width; x++ {
+		e.Board[Height-1][x] = piece.O.Tint
+	}
+
+	e.clearLines()
+
+	if e.Level != 1 {
+		t.Fatalf("Level = %d, want 1 after crossing 10 lines cleared", e.Level)
+	}
+}
+
+func TestEngine_HoldOncePerPiece(t *testing.T) {
+	e := newTestEngine(piece.T, piece.O, piece.I)
+	firstTint := e.Falling.Tint
+
+	if !e.Hold() {
+		t.Fatal("Hold() = false, want true on first hold")
+	}
+	if e.HoldPiece == nil || e.HoldPiece.Tint != firstTint {
+		t.Fatalf("HoldPiece = %v, want the original falling piece", e.HoldPiece)
+	}
+	if e.Falling.Tint == firstTint {
+		t.Fatalf("Falling piece did not advance after holding")
+	}
+	if e.Hold() {
+		t.Fatal("Hold() = true on second call, want false (already held this turn)")
+	}
+}
+
+func TestEngine_HoldSwapsBack(t *testing.T) {
+	e := newTestEngine(piece.T, piece.O, piece.I)
+	e.Hold()
+	falling := e.Falling.Tint
+
+	e.DidHoldPiece = false // simulate the next piece having locked
+	if !e.Hold() {
+		t.Fatal("Hold() = false, want true")
+	}
+	if e.Falling.Tint != piece.T.Tint {
+		t.Fatalf("Falling = %v, want the originally-held T piece back", e.Falling.Tint)
+	}
+	if e.HoldPiece.Tint != falling {
+		t.Fatalf("HoldPiece = %v, want the piece that was just falling", e.HoldPiece.Tint)
+	}
+}
+
+func TestEngine_LockDelay(t *testing.T) {
+	e := newTestEngine(piece.O, piece.T)
+	for e.canMoveDown(e.Falling) {
+		e.Falling.Y++
+	}
+	before := e.Falling.Tint
+
+	// Fewer ticks than the last-chance threshold must not commit the piece.
+	e.Tick(119)
+	if e.Falling.Tint != before {
+		t.Fatalf("piece locked before the last-chance delay elapsed")
+	}
+
+	// Crossing the threshold commits it and loads the next piece.
+	e.Tick(2)
+	if e.Falling.Tint == before {
+		t.Fatalf("piece did not lock after the last-chance delay elapsed")
+	}
+}
+
+func TestEngine_AddGarbage(t *testing.T) {
+	e := newTestEngine(piece.O)
+	e.Board[Height-1][0] = piece.O.Tint
+
+	if !e.AddGarbage(2, 3) {
+		t.Fatal("AddGarbage() = false, want true with an otherwise empty board")
+	}
+
+	if e.Board[Height-1][0] == 0 {
+		t.Fatalf("existing row was not shifted up by the garbage rows")
+	}
+	for _, y := range []int{Height - 1, Height - 2} {
+		for x := 0; x < Width; x++ {
+			want := cell.Wall
+			if x == 3 {
+				want = 0
+			}
+			if e.Board[y][x] != want {
+				t.Fatalf("Board[%d][%d] = %v, want %v", y, x, e.Board[y][x], want)
+			}
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package engine
+
+// EventType identifies what happened to the engine as the result of a Step or Tick.
+type EventType int
+
+const (
+	// EventMoved is emitted when the falling piece translates left, right, or down.
+	EventMoved EventType = iota
+	// EventRotated is emitted when the falling piece successfully rotates, with or without a kick.
+	EventRotated
+	// EventHeld is emitted when the falling piece is swapped into the hold slot.
+	EventHeld
+	// EventLocked is emitted every time a piece locks into the board, regardless of whether any
+	// lines were cleared.
+	EventLocked
+	// EventLinesCleared is emitted alongside EventLocked when locking the piece clears 1 or more
+	// lines.
+	EventLinesCleared
+	// EventTSpin is emitted alongside EventLocked when the lock qualifies for a T-spin bonus.
+	EventTSpin
+	// EventGameOver is emitted when the newly-spawned piece has nowhere to go.
+	EventGameOver
+)
+
+// Event describes a single notable occurrence produced by Step or Tick, for an adapter (rendering,
+// sound, networking) to react to.
+type Event struct {
+	Type EventType
+	// Lines is the number of lines cleared, set on EventLinesCleared and EventTSpin.
+	Lines int
+	// Mini is set on EventTSpin to distinguish a mini T-spin from a full one.
+	Mini bool
+	// Score is the number of points awarded for this event, set on EventTSpin.
+	Score int
+}
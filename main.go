@@ -2,13 +2,21 @@ package main
 
 import (
 	_ "embed"
+	"flag"
 	"fmt"
 	"image/color"
 	"log"
+	"os"
 	"strings"
+	"time"
 
+	"github.com/deitrix/tetris/ai"
 	"github.com/deitrix/tetris/cell"
+	"github.com/deitrix/tetris/config"
+	"github.com/deitrix/tetris/engine"
+	"github.com/deitrix/tetris/netplay"
 	"github.com/deitrix/tetris/piece"
+	"github.com/deitrix/tetris/replay"
 	"github.com/deitrix/tetris/sprite"
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/inpututil"
@@ -17,128 +25,105 @@ import (
 	"golang.org/x/image/font/opentype"
 )
 
+// recordingPath is where F5/F6 record to and play back from. A real save dialog is out of scope
+// for this adapter.
+const recordingPath = "recording.tetrep"
+
 const (
 	// cellSize is the size of each cell in pixels
 	cellSize = 64
-	// boardWidth is the width of the board, not including the walls
-	boardWidth = 10
-	// boardHeight is the height of the board, not including the floor
-	boardHeight = 20
 	// floorThickness is the thickness of the floor at the bottom of the board. Must be at least 1.
 	floorThickness = 1
 	// wallThickness is the thickness of the walls on the left and right sides of the board. Must be
 	// at least 1.
 	wallThickness = 1
 	// queueSize is the number of pieces that are shown in the queue
-	queueSize = 3
+	queueSize = engine.QueueSize
 )
 
-// fallSpeed is the number of frames between each automatic fall of the piece at each level
-var fallSpeed = map[int]int{
-	0:  53,
-	1:  49,
-	2:  45,
-	3:  41,
-	4:  37,
-	5:  33,
-	6:  28,
-	7:  22,
-	8:  17,
-	9:  11,
-	10: 10,
-	11: 9,
-	12: 8,
-	13: 7,
-	14: 6,
-	16: 5,
-	18: 4,
-	20: 3,
-	22: 2,
-	29: 1,
-}
-
-var lineScore = map[int]int{
-	1: 40,
-	2: 100,
-	3: 300,
-	4: 1200,
-}
-
-func getLineScore(level, n int) int {
-	return lineScore[n] * (level + 1)
-}
-
-func getFallSpeed(level int) int {
-	if level > 29 {
-		return 1
-	}
-	if speed, ok := fallSpeed[level]; ok {
-		return speed
-	}
-	return getFallSpeed(level - 1)
-}
-
 const (
-	boardWidthWithWalls  = boardWidth + wallThickness*2
-	boardHeightWithFloor = boardHeight + floorThickness
-	cellCount            = boardWidthWithWalls * boardHeightWithFloor
+	boardWidthWithWalls  = engine.Width + wallThickness*2
+	boardHeightWithFloor = engine.Height + floorThickness
 )
 
-type Cell struct {
-	Tint cell.Tint
-}
-
+// Game is a thin ebiten adapter: it forwards input to an engine.Engine and renders its state. It
+// has no game rules of its own.
 type Game struct {
-	// Cells holds the board state, not including the falling piece
-	Cells []*Cell
-	// Queue is the next 3 pieces that will fall
-	Queue [queueSize]piece.Piece
-	// DidHoldPiece is a flag that prevents the player from holding a piece more than once per turn.
-	DidHoldPiece bool
-	// HoldPiece is the piece that the player has held for later
-	HoldPiece *piece.Piece
-	// FallingPiece is the piece currently being controlled by the player
-	FallingPiece piece.Piece
-	// FastFalling is a flag that indicates whether the player is currently fast-falling the piece
-	FastFalling bool
-	// OpacityDirection is a flag that indicates whether the opacity of the piece is currently
-	// increasing or decreasing.
+	// Engine holds the actual game state and rules.
+	Engine *engine.Engine
+	// Opacity is the current render opacity of the falling piece, used to flash it during the
+	// "last chance" period before it locks. This is purely cosmetic, so it lives here rather than
+	// in the engine.
+	Opacity int
+	// OpacityDirection is a flag that indicates whether Opacity is currently increasing or
+	// decreasing.
 	OpacityDirection bool
-	// TicksSinceFall is the number of ticks since the piece last fell. This is used to determine
-	// when the piece should fall automatically.
-	TicksSinceFall int
-	// TicksSinceMove is the number of ticks since the piece last moved. This is used during the
-	// "last chance" period to determine when the piece should be committed.
-	TicksSinceMove int
-	// LastChanceTicks is the number of ticks since the piece landed. This is used to determine when
-	// the piece should be committed during the "last chance" period.
-	LastChanceTicks int
 	// ScreenWidth is the width of the screen in pixels
 	ScreenWidth int
 	// ScreenHeight is the height of the screen in pixels
 	ScreenHeight int
-	// Level is the current level of the game
-	Level int
-	// Score is the current score of the game
-	Score int
-	// LinesCleared is the number of lines that have been cleared in the game
-	LinesCleared int
 	// ShowDebug is a flag that indicates whether debug information should be shown
 	ShowDebug bool
-}
-
-func NewGame() *Game {
-	g := &Game{
-		Cells: make([]*Cell, cellCount),
+	// Seed is the seed the engine's randomizer was created with, recorded into any replay started
+	// from this game.
+	Seed int64
+	// Recording is the in-progress replay recorder, or nil if not currently recording.
+	Recording *replay.Recorder
+	recordFile *os.File
+	recordTick int
+	// Playback is the replay currently being fed into Engine, or nil if not currently playing one
+	// back.
+	Playback     *replay.Replay
+	playbackTick int
+	playbackNext int
+	playbackLast engine.Input
+	// AI drives the engine in place of keyboard/playback input while non-nil.
+	AI *ai.Player
+	// Net is the lockstep connection to an opponent, or nil for a single-player game.
+	Net *netplay.Conn
+	// Opponent mirrors the remote peer's engine in a versus match, or nil for a single-player game.
+	Opponent *engine.Engine
+	// Paused suspends stepping the engine while true.
+	Paused bool
+	// Config holds the rules and control profile this game was started with.
+	Config config.Config
+}
+
+func NewGame(cfg config.Config) *Game {
+	seed := time.Now().UnixNano()
+	e := engine.NewWithRules(cfg.NewRandomizer(seed), cfg.Rules())
+	e.Level = cfg.StartLevel
+	return &Game{
+		Engine:  e,
+		Opacity: 255,
+		Seed:    seed,
+		Config:  cfg,
+	}
+}
+
+// NewVersusGame starts a two-player game synchronized over conn, with both peers' engines seeded
+// and ruled identically so they stay in lockstep.
+func NewVersusGame(conn *netplay.Conn, cfg config.Config) *Game {
+	e := engine.NewWithRules(cfg.NewRandomizer(conn.Seed), cfg.Rules())
+	e.Level = cfg.StartLevel
+	opponent := engine.NewWithRules(cfg.NewRandomizer(conn.Seed), cfg.Rules())
+	opponent.Level = cfg.StartLevel
+	return &Game{
+		Engine:   e,
+		Opponent: opponent,
+		Opacity:  255,
+		Seed:     conn.Seed,
+		Net:      conn,
+		Config:   cfg,
 	}
-	g.fillQueue()
-	g.placeBorderCells()
-	g.loadNextPiece()
-	return g
 }
 
 func (g *Game) Reset() {
-	*g = *NewGame()
+	if g.Net != nil {
+		// Resetting would desync the two peers' engines, so it's disabled for versus matches.
+		return
+	}
+	*g = *NewGame(g.Config)
 }
 
 func (g *Game) Update() error {
@@ -152,288 +137,284 @@ func (g *Game) Update() error {
 		return nil
 	}
 
-	if inpututil.IsKeyJustPressed(ebiten.KeySpace) {
-		g.Score += g.earlyCommitScore()
-		g.commitPiece()
-		return nil
+	if inpututil.IsKeyJustPressed(g.Config.Keys.Pause) {
+		g.Paused = !g.Paused
 	}
-
-	if inpututil.IsKeyJustPressed(ebiten.KeyC) && !g.DidHoldPiece {
-		g.holdPiece()
+	if g.Paused {
 		return nil
 	}
 
-	var didMove bool
-	if (inpututil.IsKeyJustPressed(ebiten.KeyLeft) || inpututil.KeyPressDuration(ebiten.KeyLeft) > 10) && g.canMoveLeft() {
-		g.FallingPiece.X--
-		didMove = true
+	if inpututil.IsKeyJustPressed(ebiten.KeyF5) {
+		g.toggleRecording()
 	}
-
-	if (inpututil.IsKeyJustPressed(ebiten.KeyRight) || inpututil.KeyPressDuration(ebiten.KeyRight) > 10) && g.canMoveRight() {
-		g.FallingPiece.X++
-		didMove = true
+	if inpututil.IsKeyJustPressed(ebiten.KeyF6) {
+		g.startPlayback()
 	}
-
-	if inpututil.IsKeyJustPressed(ebiten.KeyUp) && g.canRotate() {
-		g.FallingPiece.Rotate()
-		didMove = true
+	if inpututil.IsKeyJustPressed(ebiten.KeyP) {
+		g.toggleAI()
 	}
 
-	minTicksSinceFall := getFallSpeed(g.Level)
-	if ebiten.IsKeyPressed(ebiten.KeyDown) {
-		minTicksSinceFall = 1
-		g.FastFalling = true
-		if g.canMoveDown(g.FallingPiece) {
-			didMove = true
+	var input engine.Input
+	switch {
+	case g.AI != nil:
+		input = g.AI.Step(g.Engine)
+	case g.Playback != nil:
+		input = g.nextPlaybackInput()
+	default:
+		input = engine.Input{
+			Left:      g.keyRepeating(g.Config.Keys.Left),
+			Right:     g.keyRepeating(g.Config.Keys.Right),
+			SoftDrop:  ebiten.IsKeyPressed(g.Config.Keys.SoftDrop),
+			HardDrop:  inpututil.IsKeyJustPressed(g.Config.Keys.HardDrop),
+			Hold:      g.Config.HoldEnabled && inpututil.IsKeyJustPressed(g.Config.Keys.Hold),
+			RotateCW:  inpututil.IsKeyJustPressed(g.Config.Keys.RotateCW),
+			RotateCCW: inpututil.IsKeyJustPressed(g.Config.Keys.RotateCCW),
 		}
-	} else {
-		g.FastFalling = false
 	}
 
-	if didMove {
-		g.TicksSinceMove = 0
+	if g.Net != nil {
+		g.stepVersus(input)
 	} else {
-		g.TicksSinceMove++
+		g.Engine.Step(input)
 	}
-	g.fall(minTicksSinceFall)
-	return nil
-}
-
-func (g *Game) Draw(screen *ebiten.Image) {
-	g.drawCells(screen)
-	g.renderPiece(screen, sprite.Ghost, g.ghostPiece(), 6*cellSize, 0)
-	g.renderPiece(screen, sprite.Cell, g.FallingPiece, 6*cellSize, 0)
-	g.drawQueue(screen)
-	g.drawHeld(screen)
-	g.drawScore(screen)
-	g.drawDebug(screen)
-}
+	g.updateOpacity()
 
-func (g *Game) Layout(_, _ int) (screenWidth, screenHeight int) {
-	g.ScreenWidth = 6*cellSize + boardWidthWithWalls*cellSize + 6*cellSize
-	g.ScreenHeight = max(boardHeightWithFloor*cellSize, cellSize+3*queueSize*cellSize)
-	return g.ScreenWidth, g.ScreenHeight
-}
-
-func (g *Game) canMoveLeft() bool {
-	for i := range g.FallingPiece.Mask {
-		if g.FallingPiece.Mask[i] == 0 {
-			continue
-		}
-		x := g.FallingPiece.X + i%g.FallingPiece.Width
-		y := g.FallingPiece.Y + i/g.FallingPiece.Width
-		if g.Cells[y*boardWidthWithWalls+x-1] != nil {
-			return false
+	if g.Recording != nil {
+		if err := g.Recording.Record(g.recordTick, input); err != nil {
+			log.Printf("recording tick %d: %v", g.recordTick, err)
 		}
+		g.recordTick++
 	}
-	return true
+	return nil
 }
 
-func (g *Game) canMoveRight() bool {
-	for i := range g.FallingPiece.Mask {
-		if g.FallingPiece.Mask[i] == 0 {
-			continue
+// toggleRecording starts recording input to recordingPath, or stops and finalizes the in-progress
+// recording if one is active.
+func (g *Game) toggleRecording() {
+	if g.Recording != nil {
+		if err := g.Recording.Close(replay.Footer{
+			FinalScore: g.Engine.Score,
+			FinalLines: g.Engine.LinesCleared,
+			FinalTick:  g.recordTick - 1,
+		}); err != nil {
+			log.Printf("closing recording: %v", err)
 		}
-		x := g.FallingPiece.X + i%g.FallingPiece.Width
-		y := g.FallingPiece.Y + i/g.FallingPiece.Width
-		if g.Cells[y*boardWidthWithWalls+x+1] != nil {
-			return false
+		if err := g.recordFile.Close(); err != nil {
+			log.Printf("closing recording file: %v", err)
 		}
+		g.Recording = nil
+		g.recordFile = nil
+		return
 	}
-	return true
-}
 
-func (g *Game) canMoveDown(p piece.Piece) bool {
-	for i := range p.Mask {
-		if p.Mask[i] == 0 {
-			continue
-		}
-		x := p.X + i%p.Width
-		y := p.Y + i/p.Width
-		if g.Cells[(y+1)*boardWidthWithWalls+x] != nil {
-			return false
-		}
+	f, err := os.Create(recordingPath)
+	if err != nil {
+		log.Printf("starting recording: %v", err)
+		return
+	}
+	rec, err := replay.NewRecorder(f, g.Seed, replay.Ruleset{
+		SRS:        true,
+		SevenBag:   true,
+		StartLevel: g.Engine.Level,
+	})
+	if err != nil {
+		log.Printf("starting recording: %v", err)
+		f.Close()
+		return
 	}
-	return true
+	g.recordFile = f
+	g.Recording = rec
+	g.recordTick = 0
 }
 
-func (g *Game) ghostPiece() piece.Piece {
-	p := g.FallingPiece
-	for {
-		if !g.canMoveDown(p) {
-			break
-		}
-		p.Y++
+// startPlayback loads recordingPath and begins feeding it into a fresh engine, replacing whatever
+// game is currently in progress.
+func (g *Game) startPlayback() {
+	f, err := os.Open(recordingPath)
+	if err != nil {
+		log.Printf("loading replay: %v", err)
+		return
 	}
-	return p
-}
+	defer f.Close()
 
-func (g *Game) earlyCommitScore() int {
-	p := g.FallingPiece
-	for i := 0; ; i++ {
-		if !g.canMoveDown(p) {
-			return i * 2
-		}
-		p.Y++
+	rep, err := replay.Load(f)
+	if err != nil {
+		log.Printf("loading replay: %v", err)
+		return
 	}
+
+	g.Playback = rep
+	g.Engine = rep.NewEngine()
+	g.playbackTick = 0
+	g.playbackNext = 0
+	g.playbackLast = engine.Input{}
 }
 
-func (g *Game) canRotate() bool {
-	if len(g.FallingPiece.Mask) == 4 {
-		return false
+// nextPlaybackInput returns the input recorded for the current playback tick and advances the
+// playback clock, ending playback once the recording is exhausted.
+func (g *Game) nextPlaybackInput() engine.Input {
+	for g.playbackNext < len(g.Playback.Events) && g.Playback.Events[g.playbackNext].Tick == g.playbackTick {
+		g.playbackLast = g.Playback.Events[g.playbackNext].Input
+		g.playbackNext++
 	}
-	p := g.FallingPiece.Clone()
-	p.Rotate()
-	for i := range p.Mask {
-		if p.Mask[i] == 0 {
-			continue
-		}
-		x := p.X + i%p.Width
-		y := p.Y + i/p.Width
-		if g.Cells[y*boardWidthWithWalls+x] != nil {
-			return false
-		}
+	input := g.playbackLast
+	if g.playbackTick >= g.Playback.FinalTick() {
+		g.Playback = nil
+	} else {
+		g.playbackTick++
 	}
-	return true
+	return input
 }
 
-// commitPiece commits the currently falling piece into the board, such that it can no longer be
-// moved. It also loads the next piece into the falling piece, and clears any lines that have been
-// filled.
-func (g *Game) commitPiece() {
-	for g.canMoveDown(g.FallingPiece) {
-		g.FallingPiece.Y++
+// keyRepeating reports whether key should register as pressed this frame, applying the
+// configured delayed-auto-shift and auto-repeat-rate: true on the initial press, then false until
+// DASFrames have elapsed, then true every ARRFrames frames (or every frame if ARRFrames is 0).
+func (g *Game) keyRepeating(key ebiten.Key) bool {
+	if inpututil.IsKeyJustPressed(key) {
+		return true
 	}
-	for i := range g.FallingPiece.Mask {
-		if g.FallingPiece.Mask[i] == 0 {
-			continue
-		}
-		x := g.FallingPiece.X + i%g.FallingPiece.Width
-		y := g.FallingPiece.Y + i/g.FallingPiece.Width
-		g.Cells[y*boardWidthWithWalls+x] = &Cell{
-			Tint: g.FallingPiece.Tint,
-		}
+	held := inpututil.KeyPressDuration(key)
+	if held <= g.Config.DASFrames {
+		return false
+	}
+	if g.Config.ARRFrames <= 0 {
+		return true
 	}
-	g.loadNextPiece()
-	g.clearLines()
-	g.DidHoldPiece = false
-	g.LastChanceTicks = 0
+	return (held-g.Config.DASFrames)%g.Config.ARRFrames == 0
 }
 
-func (g *Game) holdPiece() {
-	if g.HoldPiece == nil {
-		p := g.FallingPiece.Clone()
-		g.HoldPiece = &p
-		g.loadNextPiece()
-	} else {
-		g.FallingPiece, *g.HoldPiece = *g.HoldPiece, g.FallingPiece
-	}
-	g.FallingPiece.ResetRotation()
-	g.FallingPiece.X = boardWidthWithWalls/2 - g.FallingPiece.Width/2
-	g.FallingPiece.Y = 0
-	g.HoldPiece.ResetRotation()
-	g.HoldPiece.X = 0
-	g.HoldPiece.Y = 0
-	g.DidHoldPiece = true
+// toggleAI starts the AI playing in place of keyboard input, or stops it and returns control to
+// the keyboard if it's already playing.
+func (g *Game) toggleAI() {
+	if g.AI != nil {
+		g.AI = nil
+		return
+	}
+	g.AI = ai.NewPlayer(ai.DefaultEvaluator{Weights: ai.DefaultWeights})
 }
 
-func (g *Game) fall(minTicksSinceFall int) {
-	if g.canMoveDown(g.FallingPiece) {
-		g.FallingPiece.Opacity = 255
-		if g.TicksSinceFall >= minTicksSinceFall {
-			g.FallingPiece.Y++
-			if g.FastFalling {
-				g.Score++
+// stepVersus exchanges input with the opponent for the current tick, applies any garbage that
+// arrived from them, then advances both engines - the local one with input, the opponent's with
+// whatever they sent. If a local line clear earns garbage, it's sent to them afterwards, and the
+// same garbage is applied to g.Opponent too, so our local mirror of their board stays in sync with
+// what the real remote engine is about to do with it.
+func (g *Game) stepVersus(input engine.Input) {
+	opponentInput, err := g.Net.Exchange(input)
+	if err != nil {
+		log.Printf("netplay: %v", err)
+		return
+	}
+	g.Net.ApplyPendingGarbage(g.Engine)
+
+	for _, ev := range g.Engine.Step(input) {
+		if ev.Type == engine.EventLinesCleared {
+			n, hole, ok, err := g.Net.SendGarbage(ev.Lines)
+			if err != nil {
+				log.Printf("netplay: sending garbage: %v", err)
+			} else if ok {
+				g.Opponent.AddGarbage(n, hole)
 			}
-			g.TicksSinceFall = 0
-			g.TicksSinceMove = 0
-		} else {
-			g.TicksSinceFall++
 		}
-	} else if g.TicksSinceMove >= 30 || g.LastChanceTicks >= 120 {
-		g.commitPiece()
-		g.TicksSinceFall = 0
+	}
+	g.Opponent.Step(opponentInput)
+}
+
+// updateOpacity flashes Opacity between 128 and 255 while the falling piece is resting in its
+// "last chance" period before locking, and snaps it back to fully opaque otherwise.
+func (g *Game) updateOpacity() {
+	if g.Engine.CanFall() {
+		g.Opacity = 255
+		return
+	}
+	if g.OpacityDirection {
+		g.Opacity += g.Config.OpacityStep
+		if g.Opacity >= 255 {
+			g.Opacity = 255
+			g.OpacityDirection = false
+		}
 	} else {
-		g.LastChanceTicks++
-		if g.OpacityDirection {
-			g.FallingPiece.Opacity += 8
-			if g.FallingPiece.Opacity >= 255 {
-				g.FallingPiece.Opacity = 255
-				g.OpacityDirection = false
-			}
-		} else {
-			g.FallingPiece.Opacity -= 8
-			if g.FallingPiece.Opacity <= 128 {
-				g.FallingPiece.Opacity = 128
-				g.OpacityDirection = true
-			}
+		g.Opacity -= g.Config.OpacityStep
+		if g.Opacity <= 128 {
+			g.Opacity = 128
+			g.OpacityDirection = true
 		}
 	}
 }
 
-func (g *Game) loadNextPiece() {
-	g.FallingPiece = g.Queue[0]
-	for i := 0; i < queueSize-1; i++ {
-		g.Queue[i] = g.Queue[i+1]
+func (g *Game) Draw(screen *ebiten.Image) {
+	g.drawCells(screen, g.Engine, 6*cellSize)
+	if g.Opponent != nil {
+		g.drawCells(screen, g.Opponent, (6+boardWidthWithWalls+6)*cellSize)
 	}
-	g.Queue[queueSize-1] = piece.Rand()
-	g.FallingPiece.X = boardWidthWithWalls/2 - g.FallingPiece.Width/2
-	g.FallingPiece.Y = 0
-}
 
-func (g *Game) clearLines() {
-	lines := 0
-	for y := 0; y < boardHeight; y++ {
-		full := true
-		for x := wallThickness; x < boardWidthWithWalls-wallThickness; x++ {
-			if g.Cells[y*boardWidthWithWalls+x] == nil {
-				full = false
-				break
-			}
-		}
-		if full {
-			g.removeRow(y)
-			lines++
-		}
+	if g.Config.ShowGhost {
+		ghost := g.Engine.GhostPiece()
+		ghost.Opacity = g.Opacity
+		g.renderPiece(screen, sprite.Ghost, ghost, (6+wallThickness)*cellSize, 0)
 	}
-	if lines > 0 {
-		g.Score += getLineScore(g.Level, lines)
-		g.LinesCleared += lines
-		g.Level = min(g.LinesCleared/10, 29)
+
+	falling := g.Engine.Falling
+	falling.Opacity = g.Opacity
+	g.renderPiece(screen, sprite.Cell, falling, (6+wallThickness)*cellSize, 0)
+
+	g.drawQueue(screen)
+	g.drawHeld(screen)
+	g.drawScore(screen)
+	g.drawDebug(screen)
+	g.drawModeIndicator(screen)
+}
+
+// drawModeIndicator shows a small label while recording, replaying a .tetrep file, or letting the
+// AI play, so it's obvious from the window alone which mode is active.
+func (g *Game) drawModeIndicator(screen *ebiten.Image) {
+	switch {
+	case g.Recording != nil:
+		drawText(screen, sprite.Roboto, "REC", 32, 24, 24, color.RGBA{R: 255, A: 255})
+	case g.Playback != nil:
+		drawText(screen, sprite.Roboto, "PLAYBACK", 32, 24, 24, color.RGBA{G: 200, B: 255, A: 255})
+	case g.AI != nil:
+		drawText(screen, sprite.Roboto, "AI", 32, 24, 24, color.RGBA{G: 255, A: 255})
 	}
 }
 
-func (g *Game) removeRow(row int) {
-	for y := row; y > 0; y-- {
-		for x := wallThickness; x < boardWidthWithWalls-wallThickness; x++ {
-			g.Cells[y*boardWidthWithWalls+x] = g.Cells[(y-1)*boardWidthWithWalls+x]
-		}
+func (g *Game) Layout(_, _ int) (screenWidth, screenHeight int) {
+	g.ScreenWidth = 6*cellSize + boardWidthWithWalls*cellSize + 6*cellSize
+	if g.Opponent != nil {
+		g.ScreenWidth += boardWidthWithWalls*cellSize + 6*cellSize
 	}
+	g.ScreenHeight = max(boardHeightWithFloor*cellSize, cellSize+3*queueSize*cellSize)
+	return g.ScreenWidth, g.ScreenHeight
 }
 
-func (g *Game) drawCells(screen *ebiten.Image) {
+// drawCells renders e's board, with a one-cell wall/floor border, at xOffset pixels from the left
+// of the screen. Used for both the local player's board and, in a versus match, the opponent's.
+func (g *Game) drawCells(screen *ebiten.Image, e *engine.Engine, xOffset int) {
 	for x := 0; x < boardWidthWithWalls; x++ {
 		for y := 0; y < boardHeightWithFloor; y++ {
-			i := y*boardWidthWithWalls + x
-			if g.Cells[i] == nil {
+			if x < wallThickness || x >= boardWidthWithWalls-wallThickness || y >= boardHeightWithFloor-floorThickness {
+				drawCell(screen, sprite.Cell, xOffset+x*cellSize, y*cellSize, cellSize, cellSize, cell.Wall, 255)
 				continue
 			}
-			drawCell(screen, sprite.Cell, 6*cellSize+x*cellSize, y*cellSize, cellSize, cellSize, g.Cells[i].Tint, 255)
+			tint := e.Board[y][x-wallThickness]
+			if tint == 0 {
+				continue
+			}
+			drawCell(screen, sprite.Cell, xOffset+x*cellSize, y*cellSize, cellSize, cellSize, tint, 255)
 		}
 	}
 }
 
 func (g *Game) drawQueue(screen *ebiten.Image) {
-	for i, p := range g.Queue {
+	for i, p := range g.Engine.Queue {
 		p = p.TrimSpace()
 		xoff := (6+boardWidthWithWalls+3)*cellSize - p.Width*cellSize/2
 		yoff := 2*cellSize + i*(3*cellSize) - p.Height*cellSize/2
-		g.renderPiece(screen, sprite.Cell, p.TrimSpace(), xoff, yoff)
+		g.renderPiece(screen, sprite.Cell, p, xoff, yoff)
 	}
 }
 
 func (g *Game) drawHeld(screen *ebiten.Image) {
-	if p := g.HoldPiece; p != nil {
+	if p := g.Engine.HoldPiece; p != nil {
 		p := p.TrimSpace()
 		xoff := 3*cellSize - p.Width*cellSize/2
 		yoff := 2*cellSize - p.Height*cellSize/2
@@ -443,11 +424,11 @@ func (g *Game) drawHeld(screen *ebiten.Image) {
 
 func (g *Game) drawScore(screen *ebiten.Image) {
 	drawText(screen, sprite.Roboto, "Score", 48, 24, g.ScreenHeight-168, color.White)
-	drawText(screen, sprite.Roboto, fmt.Sprintf("%d", g.Score), 48, 192, g.ScreenHeight-168, color.White)
+	drawText(screen, sprite.Roboto, fmt.Sprintf("%d", g.Engine.Score), 48, 192, g.ScreenHeight-168, color.White)
 	drawText(screen, sprite.Roboto, "Level", 48, 24, g.ScreenHeight-96, color.White)
-	drawText(screen, sprite.Roboto, fmt.Sprintf("%d", g.Level+1), 48, 192, g.ScreenHeight-96, color.White)
+	drawText(screen, sprite.Roboto, fmt.Sprintf("%d", g.Engine.Level+1), 48, 192, g.ScreenHeight-96, color.White)
 	drawText(screen, sprite.Roboto, "Lines", 48, 24, g.ScreenHeight-24, color.White)
-	drawText(screen, sprite.Roboto, fmt.Sprintf("%d", g.LinesCleared), 48, 192, g.ScreenHeight-24, color.White)
+	drawText(screen, sprite.Roboto, fmt.Sprintf("%d", g.Engine.LinesCleared), 48, 192, g.ScreenHeight-24, color.White)
 }
 
 func (g *Game) drawDebug(screen *ebiten.Image) {
@@ -457,13 +438,12 @@ func (g *Game) drawDebug(screen *ebiten.Image) {
 	drawText(screen, sprite.Roboto, strings.Join([]string{
 		fmt.Sprintf("FPS: %0.2f", ebiten.ActualFPS()),
 		fmt.Sprintf("TPS: %0.2f", ebiten.CurrentTPS()),
-		fmt.Sprintf("Fall Speed: %d", getFallSpeed(g.Level)),
-		fmt.Sprintf("Ticks Since Fall: %d", g.TicksSinceFall),
-		fmt.Sprintf("Ticks Since Move: %d", g.TicksSinceMove),
-		fmt.Sprintf("Last Chance Ticks: %d", g.LastChanceTicks),
-		fmt.Sprintf("Fast Falling: %t", g.FastFalling),
-		fmt.Sprintf("Did Hold Piece: %t", g.DidHoldPiece),
-		fmt.Sprintf("Early-commit Score: %d", g.earlyCommitScore()),
+		fmt.Sprintf("Ticks Since Fall: %d", g.Engine.TicksSinceFall),
+		fmt.Sprintf("Ticks Since Move: %d", g.Engine.TicksSinceMove),
+		fmt.Sprintf("Last Chance Ticks: %d", g.Engine.LastChanceTicks),
+		fmt.Sprintf("Fast Falling: %t", g.Engine.FastFalling),
+		fmt.Sprintf("Did Hold Piece: %t", g.Engine.DidHoldPiece),
+		fmt.Sprintf("Drop Distance: %d", g.Engine.DropDistance()),
 	}, "\n"), 32, 24, 256, color.White)
 }
 
@@ -507,34 +487,55 @@ func drawCell(screen *ebiten.Image, img *ebiten.Image, x, y, width, height int,
 	screen.DrawImage(img, &op)
 }
 
-func (g *Game) fillQueue() {
-	for i := 0; i < queueSize; i++ {
-		g.Queue[i] = piece.Rand()
-	}
-}
-
-func (g *Game) placeBorderCells() {
-	for x := 0; x < boardWidthWithWalls; x++ {
-		for y := 0; y < boardHeightWithFloor; y++ {
-			if x < wallThickness || x >= boardWidthWithWalls-wallThickness || y >= boardHeightWithFloor-floorThickness {
-				i := y*boardWidthWithWalls + x
-				g.Cells[i] = &Cell{Tint: cell.Wall}
-			}
+// newGame starts a single-player game, or a versus match if hostAddr or connectAddr is set.
+func newGame(hostAddr, connectAddr string, cfg config.Config) (*Game, error) {
+	switch {
+	case hostAddr != "":
+		conn, err := netplay.Host(hostAddr, time.Now().UnixNano())
+		if err != nil {
+			return nil, fmt.Errorf("hosting match on %s: %w", hostAddr, err)
 		}
+		return NewVersusGame(conn, cfg), nil
+	case connectAddr != "":
+		conn, err := netplay.Connect(connectAddr)
+		if err != nil {
+			return nil, fmt.Errorf("connecting to match at %s: %w", connectAddr, err)
+		}
+		return NewVersusGame(conn, cfg), nil
+	default:
+		return NewGame(cfg), nil
 	}
 }
 
 func main() {
 	log.SetFlags(0)
+	hostAddr := flag.String("host", "", "host a versus match on this address (e.g. :9000) and wait for an opponent to connect")
+	connectAddr := flag.String("connect", "", "connect to a versus match hosted at this address")
+	flag.Parse()
+
 	if err := sprite.Load(); err != nil {
 		log.Fatalf("failed to load sprites: %v", err)
 	}
 
+	configPath, err := config.Path()
+	if err != nil {
+		log.Fatalf("finding config path: %v", err)
+	}
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		log.Fatalf("loading config: %v", err)
+	}
+
+	game, err := newGame(*hostAddr, *connectAddr, cfg)
+	if err != nil {
+		log.Fatalf("starting game: %v", err)
+	}
+
 	ebiten.SetWindowTitle("Hello, World!")
 	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
 	ebiten.SetTPS(60)
 	ebiten.SetWindowSize(1920, 1080)
-	if err := ebiten.RunGame(NewGame()); err != nil {
+	if err := ebiten.RunGame(game); err != nil {
 		log.Fatalf("failed to run game: %v", err)
 	}
 }
@@ -0,0 +1,136 @@
+// Package netplay synchronizes two engine.Engine instances over TCP in lockstep: each tick, both
+// peers exchange the input they applied, and neither advances until it has both. Line clears are
+// translated into garbage rows sent to the opponent rather than syncing full board state - as
+// long as both peers start from the same randomizer seed (exchanged during the handshake) and
+// apply the same sequence of (local input, opponent input, garbage), their engines never diverge.
+package netplay
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+
+	"github.com/deitrix/tetris/engine"
+)
+
+// Conn is a lockstep connection to the opposing peer.
+type Conn struct {
+	conn net.Conn
+	// Seed is the randomizer seed both peers must use, so their piece queues stay identical.
+	Seed int64
+
+	// pendingGarbage holds {rows, hole} pairs received since the last ApplyPendingGarbage call.
+	pendingGarbage [][2]int
+}
+
+// Host listens on addr, accepts a single opponent connection, and sends it seed to synchronize
+// both peers' randomizers.
+func Host(addr string, seed int64) (*Conn, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("listening on %s: %w", addr, err)
+	}
+	defer ln.Close()
+
+	c, err := ln.Accept()
+	if err != nil {
+		return nil, fmt.Errorf("accepting connection: %w", err)
+	}
+	if err := binary.Write(c, binary.BigEndian, seed); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("sending seed: %w", err)
+	}
+	return &Conn{conn: c, Seed: seed}, nil
+}
+
+// Connect dials a match hosted by Host at addr and receives the seed it chose.
+func Connect(addr string) (*Conn, error) {
+	c, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("dialing %s: %w", addr, err)
+	}
+	var seed int64
+	if err := binary.Read(c, binary.BigEndian, &seed); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("reading seed: %w", err)
+	}
+	return &Conn{conn: c, Seed: seed}, nil
+}
+
+// Close closes the underlying connection.
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+// Exchange sends the local peer's input for the current tick and blocks until the opponent's
+// input for the same tick arrives, returning it. Both peers must call Exchange once per tick, in
+// the same order as every other tick-synchronized call (SendGarbage may happen in between); a
+// peer that stops calling it will stall its opponent rather than desync them.
+func (c *Conn) Exchange(input engine.Input) (engine.Input, error) {
+	if err := c.send(messageInput, encodeInput(input)); err != nil {
+		return engine.Input{}, fmt.Errorf("sending input: %w", err)
+	}
+	for {
+		typ, payload, err := c.recv()
+		if err != nil {
+			return engine.Input{}, fmt.Errorf("receiving input: %w", err)
+		}
+		if typ == messageInput {
+			return decodeInput(payload[0]), nil
+		}
+		c.pendingGarbage = append(c.pendingGarbage, [2]int{int(payload[0]), int(payload[1])})
+	}
+}
+
+// SendGarbage tells the opponent that the local player just cleared lines with a single
+// placement, translating it into the garbage rows and hole column modern versus rules award. It's
+// a no-op (ok is false) if lines doesn't earn any garbage. On success, it returns the rows and hole
+// column sent, so the caller can apply the same garbage to its own mirror of the opponent's board.
+func (c *Conn) SendGarbage(lines int) (n, hole int, ok bool, err error) {
+	n, ok = garbageLines[lines]
+	if !ok {
+		return 0, 0, false, nil
+	}
+	hole = rand.Intn(engine.Width)
+	if err := c.send(messageGarbage, byte(n), byte(hole)); err != nil {
+		return 0, 0, false, err
+	}
+	return n, hole, true, nil
+}
+
+// ApplyPendingGarbage applies every garbage row received since the last call, in the order it
+// arrived, into e.
+func (c *Conn) ApplyPendingGarbage(e *engine.Engine) {
+	for _, g := range c.pendingGarbage {
+		e.AddGarbage(g[0], g[1])
+	}
+	c.pendingGarbage = nil
+}
+
+func (c *Conn) send(typ messageType, payload ...byte) error {
+	_, err := c.conn.Write(append([]byte{byte(typ)}, payload...))
+	return err
+}
+
+func (c *Conn) recv() (messageType, []byte, error) {
+	var typBuf [1]byte
+	if _, err := io.ReadFull(c.conn, typBuf[:]); err != nil {
+		return 0, nil, err
+	}
+
+	var payload []byte
+	switch messageType(typBuf[0]) {
+	case messageInput:
+		payload = make([]byte, 1)
+	case messageGarbage:
+		payload = make([]byte, 2)
+	default:
+		return 0, nil, fmt.Errorf("unknown message type %d", typBuf[0])
+	}
+	if _, err := io.ReadFull(c.conn, payload); err != nil {
+		return 0, nil, err
+	}
+	return messageType(typBuf[0]), payload, nil
+}
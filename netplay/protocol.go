@@ -0,0 +1,74 @@
+package netplay
+
+import "github.com/deitrix/tetris/engine"
+
+const (
+	bitLeft = 1 << iota
+	bitRight
+	bitSoftDrop
+	bitHardDrop
+	bitHold
+	bitRotateCW
+	bitRotateCCW
+	bitRotate180
+)
+
+func encodeInput(in engine.Input) byte {
+	var b byte
+	if in.Left {
+		b |= bitLeft
+	}
+	if in.Right {
+		b |= bitRight
+	}
+	if in.SoftDrop {
+		b |= bitSoftDrop
+	}
+	if in.HardDrop {
+		b |= bitHardDrop
+	}
+	if in.Hold {
+		b |= bitHold
+	}
+	if in.RotateCW {
+		b |= bitRotateCW
+	}
+	if in.RotateCCW {
+		b |= bitRotateCCW
+	}
+	if in.Rotate180 {
+		b |= bitRotate180
+	}
+	return b
+}
+
+func decodeInput(b byte) engine.Input {
+	return engine.Input{
+		Left:      b&bitLeft != 0,
+		Right:     b&bitRight != 0,
+		SoftDrop:  b&bitSoftDrop != 0,
+		HardDrop:  b&bitHardDrop != 0,
+		Hold:      b&bitHold != 0,
+		RotateCW:  b&bitRotateCW != 0,
+		RotateCCW: b&bitRotateCCW != 0,
+		Rotate180: b&bitRotate180 != 0,
+	}
+}
+
+// messageType identifies the kind of message read off the wire, so a garbage message arriving
+// ahead of the input for the current tick can be told apart from it.
+type messageType byte
+
+const (
+	messageInput messageType = iota
+	messageGarbage
+)
+
+// garbageLines maps the number of lines cleared by a single placement to the number of garbage
+// rows sent to the opponent, per modern versus rules: a tetris (4 lines) always sends a flat 4,
+// smaller multi-line clears send one less than they cleared, and a single line clear sends none.
+var garbageLines = map[int]int{
+	2: 1,
+	3: 2,
+	4: 4,
+}
@@ -1,7 +1,6 @@
 package piece
 
 import (
-	"math/rand"
 	"slices"
 
 	"github.com/deitrix/tetris/cell"
@@ -142,7 +141,9 @@ func (p Piece) Clone() Piece {
 	return p
 }
 
-func (p *Piece) Rotate() {
+// RotateCW rotates the piece 90 degrees clockwise in place, with no wall/floor kick applied. O
+// never rotates, matching its trivial kick table.
+func (p *Piece) RotateCW() {
 	if p.Width < 3 {
 		return
 	}
@@ -154,20 +155,27 @@ func (p *Piece) Rotate() {
 	p.Orientation = (p.Orientation + 1) % 4
 }
 
+// RotateCCW rotates the piece 90 degrees counter-clockwise in place.
+func (p *Piece) RotateCCW() {
+	p.RotateCW()
+	p.RotateCW()
+	p.RotateCW()
+}
+
+// Rotate180 rotates the piece 180 degrees in place.
+func (p *Piece) Rotate180() {
+	p.RotateCW()
+	p.RotateCW()
+}
+
 func (p *Piece) ResetRotation() {
 	for p.Orientation != 0 {
-		p.Rotate()
+		p.RotateCW()
 	}
 }
 
 var allPieces = []Piece{I, J, L, O, S, T, Z}
 
-func Rand() Piece {
-	p := allPieces[rand.Intn(len(allPieces))].Clone()
-	p.Opacity = 255
-	return p
-}
-
 var rotateIndices = map[int][]int{
 	9: {
 		2, 5, 8,
@@ -182,6 +190,58 @@ var rotateIndices = map[int][]int{
 	},
 }
 
+// Offset is a single (dx, dy) wall/floor kick test, in board coordinates where Y increases
+// downward (the opposite of the SRS spec's Y-up convention).
+type Offset struct {
+	X, Y int
+}
+
+// orientationPair identifies a rotation as a (from, to) pair of orientations, where 0 is spawn, 1
+// is clockwise from spawn ("R"), 2 is 180 degrees ("2"), and 3 is counter-clockwise from spawn
+// ("L").
+type orientationPair [2]int
+
+// kicksJLSTZ is the SRS kick table shared by J, L, S, T and Z, keyed by (from, to) orientation.
+var kicksJLSTZ = map[orientationPair][]Offset{
+	{0, 1}: {{0, 0}, {-1, 0}, {-1, -1}, {0, 2}, {-1, 2}},
+	{1, 0}: {{0, 0}, {1, 0}, {1, 1}, {0, -2}, {1, -2}},
+	{1, 2}: {{0, 0}, {1, 0}, {1, 1}, {0, -2}, {1, -2}},
+	{2, 1}: {{0, 0}, {-1, 0}, {-1, -1}, {0, 2}, {-1, 2}},
+	{2, 3}: {{0, 0}, {1, 0}, {1, -1}, {0, 2}, {1, 2}},
+	{3, 2}: {{0, 0}, {-1, 0}, {-1, 1}, {0, -2}, {-1, -2}},
+	{3, 0}: {{0, 0}, {-1, 0}, {-1, 1}, {0, -2}, {-1, -2}},
+	{0, 3}: {{0, 0}, {1, 0}, {1, -1}, {0, 2}, {1, 2}},
+}
+
+// kicksI is the SRS kick table for I, which kicks by different magnitudes than JLSTZ.
+var kicksI = map[orientationPair][]Offset{
+	{0, 1}: {{0, 0}, {-2, 0}, {1, 0}, {-2, 1}, {1, -2}},
+	{1, 0}: {{0, 0}, {2, 0}, {-1, 0}, {2, -1}, {-1, 2}},
+	{1, 2}: {{0, 0}, {-1, 0}, {2, 0}, {-1, -2}, {2, 1}},
+	{2, 1}: {{0, 0}, {1, 0}, {-2, 0}, {1, 2}, {-2, -1}},
+	{2, 3}: {{0, 0}, {2, 0}, {-1, 0}, {2, -1}, {-1, 2}},
+	{3, 2}: {{0, 0}, {-2, 0}, {1, 0}, {-2, 1}, {1, -2}},
+	{3, 0}: {{0, 0}, {1, 0}, {-2, 0}, {1, 2}, {-2, -1}},
+	{0, 3}: {{0, 0}, {-1, 0}, {2, 0}, {-1, -2}, {2, 1}},
+}
+
+// kicksO is the trivial kick table for O, which never rotates out of its own footprint.
+var kicksO = []Offset{{0, 0}}
+
+// Kicks returns the ordered list of offsets to test when rotating a piece from orientation `from`
+// to orientation `to`. The caller should apply the first offset that results in a non-colliding
+// position; the first entry is always {0, 0}, a "basic" rotation with no kick.
+func Kicks(p Piece, from, to int) []Offset {
+	switch p.Width {
+	case 4:
+		return kicksI[orientationPair{from, to}]
+	case 3:
+		return kicksJLSTZ[orientationPair{from, to}]
+	default:
+		return kicksO
+	}
+}
+
 func newColorScale(r, g, b, a float32) ebiten.ColorScale {
 	var c ebiten.ColorScale
 	c.Scale(r, g, b, a)
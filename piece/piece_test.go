@@ -1,4 +1,4 @@
-package main
+package piece
 
 import (
 	"slices"
@@ -45,7 +45,9 @@ func TestPiece_TrimSpace(t *testing.T) {
 	}
 	for _, test := range tests {
 		p := Piece{
-			Mask: test.input,
+			Mask:   test.input,
+			Width:  3,
+			Height: 3,
 		}
 		got := p.TrimSpace()
 		if !slices.Equal(got.Mask, test.expect) {
@@ -0,0 +1,168 @@
+package piece
+
+// Randomizer yields the sequence of pieces fed to the falling-piece queue. Implementations vary in
+// how fair or how biased their piece distribution is.
+type Randomizer interface {
+	Next() Piece
+
+	// Clone returns an independent copy of the Randomizer, with identical internal state, such
+	// that calling Next() the same number of times on the original and the clone always yields the
+	// same sequence of pieces. Callers that need to speculatively advance a Randomizer (e.g. the AI
+	// search, which locks real pieces while exploring candidate placements) clone it first and
+	// discard the clone afterwards, leaving the original's state untouched.
+	Clone() Randomizer
+}
+
+// rng is a small, self-contained xorshift64* pseudo-random generator. The randomizers below use it
+// instead of math/rand.Rand because math/rand.Rand keeps its state behind an unexported Source,
+// which can't be deep-copied from outside the math/rand package - and Randomizer.Clone needs an
+// exact, independent copy of that state.
+type rng struct {
+	state uint64
+}
+
+// newRNG returns an rng seeded from seed. The all-zero state is avoided, since it's a fixed point
+// that would otherwise generate nothing but zeroes.
+func newRNG(seed int64) *rng {
+	s := uint64(seed)
+	if s == 0 {
+		s = 0x9e3779b97f4a7c15
+	}
+	return &rng{state: s}
+}
+
+// clone returns an independent copy of r with identical state.
+func (r *rng) clone() *rng {
+	cp := *r
+	return &cp
+}
+
+// next returns the generator's next pseudo-random uint64.
+func (r *rng) next() uint64 {
+	r.state ^= r.state << 13
+	r.state ^= r.state >> 7
+	r.state ^= r.state << 17
+	return r.state
+}
+
+// intn returns a pseudo-random integer in [0, n).
+func (r *rng) intn(n int) int {
+	return int(r.next() % uint64(n))
+}
+
+// shuffle randomizes the order of n elements in place, using swap to exchange two of them, in the
+// manner of rand.Rand.Shuffle.
+func (r *rng) shuffle(n int, swap func(i, j int)) {
+	for i := n - 1; i > 0; i-- {
+		j := r.intn(i + 1)
+		swap(i, j)
+	}
+}
+
+// NewUniformRandomizer returns a Randomizer that picks each piece independently and uniformly at
+// random, with no memory of what came before. This is the original behavior of piece.Rand: simple,
+// but it allows long droughts and floods of any given piece.
+func NewUniformRandomizer(seed int64) Randomizer {
+	return &uniformRandomizer{rng: newRNG(seed)}
+}
+
+type uniformRandomizer struct {
+	rng *rng
+}
+
+func (r *uniformRandomizer) Next() Piece {
+	return newPiece(allPieces[r.rng.intn(len(allPieces))])
+}
+
+func (r *uniformRandomizer) Clone() Randomizer {
+	cp := *r
+	cp.rng = r.rng.clone()
+	return &cp
+}
+
+// NewBagRandomizer returns a Randomizer implementing the "7-bag" system used by modern Tetris
+// guideline games: each of the 7 tetrominoes appears exactly once per shuffled bag, so no piece can
+// appear more than twice in a row and every piece is seen at least once every 7 pieces.
+func NewBagRandomizer(seed int64) Randomizer {
+	return &bagRandomizer{rng: newRNG(seed)}
+}
+
+type bagRandomizer struct {
+	rng *rng
+	bag []Piece
+}
+
+func (r *bagRandomizer) Next() Piece {
+	if len(r.bag) == 0 {
+		r.bag = append(r.bag, allPieces...)
+		r.rng.shuffle(len(r.bag), func(i, j int) {
+			r.bag[i], r.bag[j] = r.bag[j], r.bag[i]
+		})
+	}
+	p := r.bag[0]
+	r.bag = r.bag[1:]
+	return newPiece(p)
+}
+
+func (r *bagRandomizer) Clone() Randomizer {
+	cp := *r
+	cp.rng = r.rng.clone()
+	cp.bag = append([]Piece(nil), r.bag...)
+	return &cp
+}
+
+// tgmHistorySize is the number of previous pieces the TGM randomizer remembers and avoids
+// repeating where possible.
+const tgmHistorySize = 4
+
+// tgmMaxRerolls is the maximum number of times the TGM randomizer will reroll a piece that matches
+// its recent history before giving up and accepting it anyway.
+const tgmMaxRerolls = 6
+
+// NewTGMRandomizer returns a Randomizer implementing the TGM (Tetris the Grand Master) history
+// randomizer: each piece is drawn uniformly, then rerolled up to tgmMaxRerolls times if it appears
+// in the last tgmHistorySize pieces dealt. This reduces floods/droughts without the strict fairness
+// (and predictability) of the 7-bag system.
+func NewTGMRandomizer(seed int64) Randomizer {
+	return &tgmRandomizer{rng: newRNG(seed)}
+}
+
+type tgmRandomizer struct {
+	rng     *rng
+	history []int
+}
+
+func (r *tgmRandomizer) Next() Piece {
+	i := r.rng.intn(len(allPieces))
+	for attempt := 0; attempt < tgmMaxRerolls && r.inHistory(i); attempt++ {
+		i = r.rng.intn(len(allPieces))
+	}
+	r.history = append(r.history, i)
+	if len(r.history) > tgmHistorySize {
+		r.history = r.history[len(r.history)-tgmHistorySize:]
+	}
+	return newPiece(allPieces[i])
+}
+
+func (r *tgmRandomizer) inHistory(i int) bool {
+	for _, h := range r.history {
+		if h == i {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *tgmRandomizer) Clone() Randomizer {
+	cp := *r
+	cp.rng = r.rng.clone()
+	cp.history = append([]int(nil), r.history...)
+	return &cp
+}
+
+// newPiece clones a canonical piece definition and resets it to a freshly-spawned state.
+func newPiece(p Piece) Piece {
+	p = p.Clone()
+	p.Opacity = 255
+	return p
+}
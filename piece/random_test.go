@@ -0,0 +1,75 @@
+package piece
+
+import "testing"
+
+func TestBagRandomizer_EachBagHasEveryPieceOnce(t *testing.T) {
+	r := NewBagRandomizer(1)
+	for bag := 0; bag < 10; bag++ {
+		seen := make(map[int]bool)
+		for i := 0; i < len(allPieces); i++ {
+			idx := pieceIndex(r.Next())
+			if seen[idx] {
+				t.Fatalf("bag %d: piece %d dealt twice", bag, idx)
+			}
+			seen[idx] = true
+		}
+		if len(seen) != len(allPieces) {
+			t.Fatalf("bag %d: saw %d distinct pieces, want %d", bag, len(seen), len(allPieces))
+		}
+	}
+}
+
+func TestTGMRandomizer_RarelyRepeatsImmediately(t *testing.T) {
+	// With 7 piece types and up to tgmMaxRerolls rerolls away from the recent history, an
+	// immediately-repeated piece should be possible but vanishingly rare. A high repeat rate would
+	// mean the reroll logic isn't consulting the history at all.
+	r := NewTGMRandomizer(1)
+	last := -1
+	repeats := 0
+	const draws = 2000
+	for i := 0; i < draws; i++ {
+		p := r.Next()
+		idx := pieceIndex(p)
+		if idx == last {
+			repeats++
+		}
+		last = idx
+	}
+	if repeats > draws/20 {
+		t.Fatalf("got %d immediate repeats in %d draws, want the reroll logic to keep this rare", repeats, draws)
+	}
+}
+
+// pieceIndex returns the index into allPieces matching p's tint, or -1 if none match.
+func pieceIndex(p Piece) int {
+	for i, candidate := range allPieces {
+		if p.Tint == candidate.Tint {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestRandomizer_CloneReproducesSameSequence(t *testing.T) {
+	for name, r := range map[string]Randomizer{
+		"uniform": NewUniformRandomizer(42),
+		"bag":     NewBagRandomizer(42),
+		"tgm":     NewTGMRandomizer(42),
+	} {
+		t.Run(name, func(t *testing.T) {
+			// Advance r partway, then clone it and check both yield the same pieces from here on -
+			// this is what lets ai.Best speculatively explore placements without desyncing the real
+			// randomizer.
+			for i := 0; i < 5; i++ {
+				r.Next()
+			}
+			clone := r.Clone()
+			for i := 0; i < 20; i++ {
+				want, got := r.Next(), clone.Next()
+				if want.Tint != got.Tint {
+					t.Fatalf("draw %d: clone diverged: want tint %v, got %v", i, want.Tint, got.Tint)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package replay
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/deitrix/tetris/engine"
+	"github.com/deitrix/tetris/piece"
+)
+
+// Event is a single recorded input change, with the tick (relative to the start of the replay)
+// it first took effect on.
+type Event struct {
+	Tick  int
+	Input engine.Input
+}
+
+// Replay is a fully-loaded recording, ready to be driven into a fresh engine via Play.
+type Replay struct {
+	Header Header
+	Events []Event
+	// Footer is the recorded outcome of the game, or nil if the replay was never closed (e.g. the
+	// game crashed or the file was truncated).
+	Footer *Footer
+}
+
+// Load reads a complete .tetrep file from r.
+func Load(r io.Reader) (*Replay, error) {
+	h, err := readHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	br := bufio.NewReader(r)
+	rep := &Replay{Header: h}
+	tick := 0
+	for {
+		delta, err := binary.ReadUvarint(br)
+		if err == io.EOF {
+			return rep, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading tick delta: %w", err)
+		}
+		code, err := br.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("reading input code: %w", err)
+		}
+		tick += int(delta)
+		if code == eofCode {
+			if footer, err := readFooter(br); err == nil {
+				rep.Footer = &footer
+			}
+			return rep, nil
+		}
+		rep.Events = append(rep.Events, Event{Tick: tick, Input: decodeInput(code)})
+	}
+}
+
+// FinalTick returns the last tick Play must reach to replay the whole recording. If the replay was
+// closed, this is the Footer's recorded FinalTick - the actual last tick the game simulated, which
+// may be well past the last recorded input change (an idle tail of gravity, or a steadily-held
+// key, changes nothing so records nothing). For a replay with no Footer (never closed, e.g. a
+// crashed session), the best available estimate is the tick of the last recorded change.
+func (rep *Replay) FinalTick() int {
+	if rep.Footer != nil {
+		return rep.Footer.FinalTick
+	}
+	return rep.lastChangeTick()
+}
+
+// lastChangeTick returns the tick at which the recorded input last changed.
+func (rep *Replay) lastChangeTick() int {
+	if len(rep.Events) == 0 {
+		return 0
+	}
+	return rep.Events[len(rep.Events)-1].Tick
+}
+
+// NewEngine constructs a fresh engine using the seed and randomizer recorded in the header, ready
+// to be driven by Play.
+func (rep *Replay) NewEngine() *engine.Engine {
+	var r piece.Randomizer
+	if rep.Header.Ruleset.SevenBag {
+		r = piece.NewBagRandomizer(rep.Header.Seed)
+	} else {
+		r = piece.NewUniformRandomizer(rep.Header.Seed)
+	}
+	e := engine.New(r)
+	e.Level = rep.Header.Ruleset.StartLevel
+	return e
+}
+
+// Play drives eng through every recorded tick in order, returning every event the engine produced
+// along the way. eng is typically constructed with NewEngine, but any engine can be replayed into
+// (e.g. to continue a live game from a recorded warm-up).
+func (rep *Replay) Play(eng *engine.Engine) []engine.Event {
+	var all []engine.Event
+	var input engine.Input
+	next := 0
+	for tick := 0; tick <= rep.FinalTick(); tick++ {
+		for next < len(rep.Events) && rep.Events[next].Tick == tick {
+			input = rep.Events[next].Input
+			next++
+		}
+		all = append(all, eng.Step(input)...)
+	}
+	return all
+}
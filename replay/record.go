@@ -0,0 +1,114 @@
+package replay
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/deitrix/tetris/engine"
+)
+
+const (
+	bitLeft = 1 << iota
+	bitRight
+	bitSoftDrop
+	bitHardDrop
+	bitHold
+	bitRotateCW
+	bitRotateCCW
+	bitRotate180
+)
+
+func encodeInput(in engine.Input) byte {
+	var b byte
+	if in.Left {
+		b |= bitLeft
+	}
+	if in.Right {
+		b |= bitRight
+	}
+	if in.SoftDrop {
+		b |= bitSoftDrop
+	}
+	if in.HardDrop {
+		b |= bitHardDrop
+	}
+	if in.Hold {
+		b |= bitHold
+	}
+	if in.RotateCW {
+		b |= bitRotateCW
+	}
+	if in.RotateCCW {
+		b |= bitRotateCCW
+	}
+	if in.Rotate180 {
+		b |= bitRotate180
+	}
+	return b
+}
+
+func decodeInput(b byte) engine.Input {
+	return engine.Input{
+		Left:      b&bitLeft != 0,
+		Right:     b&bitRight != 0,
+		SoftDrop:  b&bitSoftDrop != 0,
+		HardDrop:  b&bitHardDrop != 0,
+		Hold:      b&bitHold != 0,
+		RotateCW:  b&bitRotateCW != 0,
+		RotateCCW: b&bitRotateCCW != 0,
+		Rotate180: b&bitRotate180 != 0,
+	}
+}
+
+// Recorder writes a .tetrep replay to an underlying writer as the game is played. Only ticks where
+// the input differs from the previously-recorded input are written; playback holds the last
+// recorded input steady across the gap.
+type Recorder struct {
+	w         io.Writer
+	started   bool
+	lastTick  int
+	lastInput engine.Input
+}
+
+// NewRecorder writes a replay header to w and returns a Recorder ready to accept ticks.
+func NewRecorder(w io.Writer, seed int64, ruleset Ruleset) (*Recorder, error) {
+	h := Header{Version: Version, Seed: seed, Ruleset: ruleset}
+	if err := h.write(w); err != nil {
+		return nil, err
+	}
+	return &Recorder{w: w}, nil
+}
+
+// Record logs the input active at the given tick, if it differs from the previously recorded
+// input. tick must be non-decreasing across calls.
+func (r *Recorder) Record(tick int, input engine.Input) error {
+	if r.started && input == r.lastInput {
+		return nil
+	}
+	if err := r.writeEvent(tick-r.lastTick, encodeInput(input)); err != nil {
+		return err
+	}
+	r.lastTick = tick
+	r.lastInput = input
+	r.started = true
+	return nil
+}
+
+// Close writes the closing footer and end-of-stream marker. The replay is not valid for playback
+// verification until Close has been called.
+func (r *Recorder) Close(final Footer) error {
+	if err := r.writeEvent(0, eofCode); err != nil {
+		return err
+	}
+	return final.write(r.w)
+}
+
+func (r *Recorder) writeEvent(delta int, code byte) error {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], uint64(delta))
+	if _, err := r.w.Write(buf[:n]); err != nil {
+		return err
+	}
+	_, err := r.w.Write([]byte{code})
+	return err
+}
@@ -0,0 +1,107 @@
+package replay
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/deitrix/tetris/engine"
+	"github.com/deitrix/tetris/piece"
+)
+
+func TestRecordAndPlay_RoundTrip(t *testing.T) {
+	const seed = 7
+
+	inputs := []engine.Input{
+		{},
+		{Left: true},
+		{Left: true},
+		{},
+		{RotateCW: true},
+		{},
+		{HardDrop: true},
+		{},
+		{Right: true},
+		{Right: true},
+		{HardDrop: true},
+	}
+
+	e := engine.New(piece.NewBagRandomizer(seed))
+	var buf bytes.Buffer
+	rec, err := NewRecorder(&buf, seed, Ruleset{SevenBag: true})
+	if err != nil {
+		t.Fatalf("NewRecorder: %v", err)
+	}
+	for tick, input := range inputs {
+		if err := rec.Record(tick, input); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+		e.Step(input)
+	}
+	if err := rec.Close(Footer{FinalScore: e.Score, FinalLines: e.LinesCleared, FinalTick: len(inputs) - 1}); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	rep, err := Load(&buf)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if rep.Footer == nil {
+		t.Fatalf("Footer = nil, want a recorded footer")
+	}
+	if rep.Footer.FinalScore != e.Score || rep.Footer.FinalLines != e.LinesCleared {
+		t.Fatalf("recorded footer = %+v, want score=%d lines=%d", rep.Footer, e.Score, e.LinesCleared)
+	}
+
+	replayed := rep.NewEngine()
+	rep.Play(replayed)
+
+	if replayed.Score != e.Score {
+		t.Errorf("replayed Score = %d, want %d", replayed.Score, e.Score)
+	}
+	if replayed.LinesCleared != e.LinesCleared {
+		t.Errorf("replayed LinesCleared = %d, want %d", replayed.LinesCleared, e.LinesCleared)
+	}
+}
+
+// TestRecordAndPlay_IdleTail is a regression test for a bug where Play stopped simulating at the
+// tick of the last recorded input *change*, so an idle tail - gravity with no key held, or a
+// steadily-held key like SoftDrop with no further change - was invisible to the format and never
+// replayed.
+func TestRecordAndPlay_IdleTail(t *testing.T) {
+	const seed = 7
+	const ticks = 200
+
+	e := engine.New(piece.NewBagRandomizer(seed))
+	var buf bytes.Buffer
+	rec, err := NewRecorder(&buf, seed, Ruleset{SevenBag: true})
+	if err != nil {
+		t.Fatalf("NewRecorder: %v", err)
+	}
+	input := engine.Input{SoftDrop: true}
+	for tick := 0; tick < ticks; tick++ {
+		if err := rec.Record(tick, input); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+		e.Step(input)
+	}
+	if err := rec.Close(Footer{FinalScore: e.Score, FinalLines: e.LinesCleared, FinalTick: ticks - 1}); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if e.Score == 0 {
+		t.Fatalf("test setup: live game scored 0, the held SoftDrop never moved anything")
+	}
+
+	rep, err := Load(&buf)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	replayed := rep.NewEngine()
+	rep.Play(replayed)
+
+	if replayed.Score != e.Score {
+		t.Errorf("replayed Score = %d, want %d (idle tail after the only recorded change was dropped)", replayed.Score, e.Score)
+	}
+	if replayed.LinesCleared != e.LinesCleared {
+		t.Errorf("replayed LinesCleared = %d, want %d", replayed.LinesCleared, e.LinesCleared)
+	}
+}